@@ -0,0 +1,209 @@
+package container
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var containerOnce sync.Once
+var container *Container
+
+// Initializer 注入完成后需要初始化的对象
+type Initializer interface {
+	Init() error
+}
+
+// Container 单例依赖容器, 对象中带有'inject'标签的字段会被注入:
+// inject:"" 按类型注入, inject:"name" 按名称注入, inject:"optional" 或 inject:"name,optional" 表示可选
+type Container struct {
+	lock     sync.Mutex
+	byType   map[reflect.Type]interface{}
+	byName   map[string]interface{}
+	injected map[interface{}]bool
+	inited   []interface{}
+}
+
+// Register 按类型注册单例
+func (c *Container) Register(obj interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.byType[reflect.TypeOf(obj)] = obj
+}
+
+// RegisterNamed 按名称注册单例
+func (c *Container) RegisterNamed(name string, obj interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.byName[name] = obj
+}
+
+// Inject 注入target中带有'inject'标签的字段, target须为结构体指针.
+// 被注入的单例会先完成自身的注入和初始化(Init), 存在循环依赖或缺少依赖时返回错误
+func (c *Container) Inject(target interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.inject(target, make([]interface{}, 0))
+}
+
+// Prepare 注入target的依赖并调用其Init, 已经初始化过的对象不会重复初始化,
+// 之后由Close和单例一起按初始化的逆序关闭
+func (c *Container) Prepare(target interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.prepare(target, make([]interface{}, 0))
+}
+
+// Close 按初始化的逆序关闭实现了io.Closer的单例
+func (c *Container) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var firstErr error
+	for i := len(c.inited) - 1; i >= 0; i-- {
+		if closer, ok := c.inited[i].(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	c.inited = make([]interface{}, 0)
+	c.injected = make(map[interface{}]bool)
+	return firstErr
+}
+
+func (c *Container) inject(target interface{}, path []interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("inject target should be ptr of struct, but got %s", targetValue.Type())
+	}
+
+	structValue := targetValue.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("%s.%s should be exported to be injected", structType.String(), field.Name)
+		}
+
+		name, optional := parseInjectTag(tag)
+		dep, err := c.find(field.Type, name)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", structType.String(), field.Name, err.Error())
+		}
+		if dep == nil {
+			if optional {
+				continue
+			}
+			return fmt.Errorf("%s.%s: missing dependency %s", structType.String(), field.Name, describe(field.Type, name))
+		}
+
+		if err := c.prepare(dep, append(path, target)); err != nil {
+			return err
+		}
+		structValue.Field(i).Set(reflect.ValueOf(dep))
+	}
+	return nil
+}
+
+// prepare 完成单例自身的注入和初始化
+func (c *Container) prepare(dep interface{}, path []interface{}) error {
+	if !reflect.TypeOf(dep).Comparable() || c.injected[dep] {
+		return nil
+	}
+	for i, obj := range path {
+		if obj == dep {
+			names := make([]string, 0)
+			for _, item := range append(path[i:], dep) {
+				names = append(names, reflect.TypeOf(item).String())
+			}
+			return fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+		}
+	}
+
+	if reflect.TypeOf(dep).Kind() == reflect.Ptr && reflect.TypeOf(dep).Elem().Kind() == reflect.Struct {
+		if err := c.inject(dep, path); err != nil {
+			return err
+		}
+	}
+	if initializer, ok := dep.(Initializer); ok {
+		if err := initializer.Init(); err != nil {
+			return fmt.Errorf("init %s failed: %s", reflect.TypeOf(dep), err.Error())
+		}
+	}
+	c.injected[dep] = true
+	c.inited = append(c.inited, dep)
+	return nil
+}
+
+// find 查找依赖, 按名称查找或按类型查找, 接口类型会匹配唯一实现了它的单例
+func (c *Container) find(typ reflect.Type, name string) (interface{}, error) {
+	if name != "" {
+		dep, ok := c.byName[name]
+		if !ok {
+			return nil, nil
+		}
+		if !reflect.TypeOf(dep).AssignableTo(typ) {
+			return nil, fmt.Errorf("dependency '%s' of type %s is not assignable to %s", name, reflect.TypeOf(dep), typ)
+		}
+		return dep, nil
+	}
+
+	if dep, ok := c.byType[typ]; ok {
+		return dep, nil
+	}
+	if typ.Kind() != reflect.Interface {
+		return nil, nil
+	}
+	var found interface{}
+	for depType, dep := range c.byType {
+		if depType.Implements(typ) {
+			if found != nil {
+				return nil, fmt.Errorf("ambiguous dependency %s, more than one singleton implements it", typ)
+			}
+			found = dep
+		}
+	}
+	return found, nil
+}
+
+func parseInjectTag(tag string) (string, bool) {
+	name := ""
+	optional := false
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "optional" {
+			optional = true
+		} else if item != "" {
+			name = item
+		}
+	}
+	return name, optional
+}
+
+func describe(typ reflect.Type, name string) string {
+	if name != "" {
+		return fmt.Sprintf("'%s'", name)
+	}
+	return typ.String()
+}
+
+// GetContainer 获取依赖容器
+func GetContainer() *Container {
+	if container == nil {
+		containerOnce.Do(func() {
+			container = &Container{
+				byType:   make(map[reflect.Type]interface{}),
+				byName:   make(map[string]interface{}),
+				injected: make(map[interface{}]bool),
+				inited:   make([]interface{}, 0),
+			}
+		})
+	}
+	return container
+}
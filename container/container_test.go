@@ -0,0 +1,236 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type finder interface {
+	Find() string
+}
+
+type testDB struct {
+	log *[]string
+}
+
+func (db *testDB) Init() error  { *db.log = append(*db.log, "init db"); return nil }
+func (db *testDB) Close() error { *db.log = append(*db.log, "close db"); return nil }
+
+type testRepo struct {
+	DB  *testDB `inject:""`
+	log *[]string
+}
+
+func (repo *testRepo) Find() string { return "repo" }
+func (repo *testRepo) Init() error  { *repo.log = append(*repo.log, "init repo"); return nil }
+func (repo *testRepo) Close() error { *repo.log = append(*repo.log, "close repo"); return nil }
+
+type otherFinder struct{}
+
+func (otherFinder) Find() string { return "other" }
+
+type testCache struct{}
+
+type testService struct {
+	Repo  finder     `inject:""`
+	Cache *testCache `inject:"cache,optional"`
+	log   *[]string
+}
+
+func (service *testService) Init() error {
+	*service.log = append(*service.log, "init service "+service.Repo.Find())
+	return nil
+}
+func (service *testService) Close() error {
+	*service.log = append(*service.log, "close service")
+	return nil
+}
+
+type failingInit struct {
+	DB *testDB `inject:""`
+}
+
+func (failingInit) Init() error { return errors.New("boom") }
+
+type cycleA struct {
+	B *cycleB `inject:""`
+}
+
+type cycleB struct {
+	A *cycleA `inject:""`
+}
+
+type namedTarget struct {
+	DB *testDB `inject:"primary"`
+}
+
+type needsDB struct {
+	DB *testDB `inject:""`
+}
+
+type needsNamed struct {
+	DB *testDB `inject:"primary"`
+}
+
+type needsOptional struct {
+	DB *testDB `inject:"optional"`
+}
+
+type needsFinder struct {
+	Finder finder `inject:""`
+}
+
+type needsCycle struct {
+	A *cycleA `inject:""`
+}
+
+type unexportedInject struct {
+	db *testDB `inject:""`
+}
+
+func newTestContainer() *Container {
+	return &Container{
+		byType:   make(map[reflect.Type]interface{}),
+		byName:   make(map[string]interface{}),
+		injected: make(map[interface{}]bool),
+		inited:   make([]interface{}, 0),
+	}
+}
+
+func TestInjectErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		register func(c *Container)
+		target   interface{}
+		// err 期望的错误信息片段
+		err string
+	}{
+		{name: "missing dependency", target: &needsDB{}, err: "needsDB.DB: missing dependency *container.testDB"},
+		{name: "missing named dependency", target: &needsNamed{}, err: "missing dependency 'primary'"},
+		{name: "optional dependency", target: &needsOptional{}},
+		{
+			name:     "named dependency of wrong type",
+			register: func(c *Container) { c.RegisterNamed("primary", &testCache{}) },
+			target:   &needsNamed{},
+			err:      "dependency 'primary' of type *container.testCache is not assignable to *container.testDB",
+		},
+		{
+			name: "ambiguous interface",
+			register: func(c *Container) {
+				c.Register(&testRepo{DB: &testDB{}})
+				c.Register(otherFinder{})
+			},
+			target: &needsFinder{},
+			err:    "ambiguous dependency container.finder",
+		},
+		{
+			name: "dependency cycle",
+			register: func(c *Container) {
+				c.Register(&cycleA{})
+				c.Register(&cycleB{})
+			},
+			target: &needsCycle{},
+			err:    "dependency cycle: *container.cycleA -> *container.cycleB -> *container.cycleA",
+		},
+		{name: "unexported field", target: &unexportedInject{}, err: "unexportedInject.db should be exported"},
+		{name: "not a struct pointer", target: needsDB{}, err: "inject target should be ptr of struct"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			container := newTestContainer()
+			if c.register != nil {
+				c.register(container)
+			}
+			err := container.Inject(c.target)
+			if c.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("got error %v, want %q", err, c.err)
+			}
+		})
+	}
+}
+
+func TestInjectByInterfaceAndName(t *testing.T) {
+	log := make([]string, 0)
+	container := newTestContainer()
+	db := &testDB{log: &log}
+	repo := &testRepo{log: &log}
+	container.Register(db)
+	container.Register(repo)
+	container.RegisterNamed("primary", db)
+
+	service := &testService{log: &log}
+	if err := container.Inject(service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Repo != repo || repo.DB != db || service.Cache != nil {
+		t.Fatalf("unexpected injection %+v, repo %+v", service, repo)
+	}
+	named := &namedTarget{}
+	if err := container.Inject(named); err != nil {
+		t.Fatal(err)
+	}
+	if named.DB != db {
+		t.Fatalf("named dependency not injected")
+	}
+	// 依赖先于依赖者初始化, 并且只初始化一次
+	if want := []string{"init db", "init repo"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+}
+
+func TestPrepareAndClose(t *testing.T) {
+	log := make([]string, 0)
+	container := newTestContainer()
+	container.Register(&testDB{log: &log})
+	container.Register(&testRepo{log: &log})
+
+	service := &testService{log: &log}
+	for i := 0; i < 2; i++ {
+		if err := container.Prepare(service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := container.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"init db", "init repo", "init service repo", "close service", "close repo", "close db"}
+	if !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+
+	// 关闭后可以重新初始化
+	log = log[:0]
+	if err := container.Prepare(service); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"init db", "init repo", "init service repo"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+}
+
+func TestPrepareInitFailure(t *testing.T) {
+	log := make([]string, 0)
+	container := newTestContainer()
+	container.Register(&testDB{log: &log})
+
+	err := container.Prepare(&failingInit{})
+	if err == nil || !strings.Contains(err.Error(), "init *container.failingInit failed: boom") {
+		t.Fatalf("got error %v", err)
+	}
+	// 初始化失败的对象不会被关闭, 已经初始化的依赖会被关闭
+	if err := container.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"init db", "close db"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("got %v, want %v", log, want)
+	}
+}
@@ -1,13 +1,88 @@
 package data
 
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPRequestKey the key of route info stored in gin.Context
+const HTTPRequestKey = "autoroute.httpRequest"
+
+// RouteOf returns the route info of the current request, nil if the request is not served by a registered route
+func RouteOf(ctx *gin.Context) *HTTPRequest {
+	if val, ok := ctx.Get(HTTPRequestKey); ok {
+		if httpRequest, ok := val.(*HTTPRequest); ok {
+			return httpRequest
+		}
+	}
+	return nil
+}
+
 // HTTPRequest route info
 type HTTPRequest struct {
-	URL    string
-	Method string
-	Func   string
-	Auth   bool
-	Author string
-	Data   string
+	URL                string
+	Method             string
+	Func               string
+	Auth               bool
+	Author             string
+	Data               string
+	Uploads            []*UploadInfo
+	Params             []*ParamInfo
+	MaxBodySize        int64
+	MissingContextCode int
+	StrictBody         bool
+	CoerceBody         bool
+	Produces           []string
+	SuccessStatus      int
+	ResponseFields     []*ResponseField
+	// Stream the handler writes the body directly, such as files and event streams, no content negotiation
+	Stream bool
+	// Events the route responds with text/event-stream
+	Events bool
+	// Heartbeat interval of heartbeat comments of the event stream, 0 means no heartbeat
+	Heartbeat time.Duration
+	// Naming derives param names from field names without 'field' tag, nil means lower camel case
+	Naming func(fieldName string) string `json:"-"`
+}
+
+// UploadInfo upload field info
+type UploadInfo struct {
+	Name         string
+	Multiple     bool
+	Required     bool
+	MaxSize      int64
+	MaxCount     int
+	ContentTypes []string
+}
+
+// ParamInfo param field info, Name and From are the first of Locations
+type ParamInfo struct {
+	Field     string
+	Name      string
+	From      string
+	Locations []*ParamLocation
+	Type      string
+	Required  bool
+	Default   string
+	// Sortable fields allowed by a param.Sort param
+	Sortable []string
+	// Filterable fields and their operators allowed by a param.Filter param
+	Filterable map[string][]string
+}
+
+// ParamLocation a place where the param is looked up
+type ParamLocation struct {
+	From string
+	Name string
+}
+
+// ResponseField response field written into the header or status instead of the body
+type ResponseField struct {
+	Field string
+	To    string
+	Name  string
+	Type  string
 }
 
 // RouterContext context
@@ -5,6 +5,9 @@ type HTTPException struct {
 	Code    int
 	Message string
 	Err     error
+	Details interface{}
+	// Extensions 额外的信息, 使用problem+json格式时作为扩展成员输出
+	Extensions map[string]interface{}
 }
 
 func (exception *HTTPException) Error() string {
@@ -19,3 +22,22 @@ func New(code int, message string, err error) *HTTPException {
 		Err:     err,
 	}
 }
+
+// WithExtension 添加扩展信息
+func (exception *HTTPException) WithExtension(key string, val interface{}) *HTTPException {
+	if exception.Extensions == nil {
+		exception.Extensions = make(map[string]interface{})
+	}
+	exception.Extensions[key] = val
+	return exception
+}
+
+// NewWithDetails 创建一个带有详细信息的exception, details应当可以被序列化
+func NewWithDetails(code int, message string, err error, details interface{}) *HTTPException {
+	return &HTTPException{
+		Code:    code,
+		Message: message,
+		Err:     err,
+		Details: details,
+	}
+}
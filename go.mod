@@ -5,4 +5,6 @@ go 1.15
 require (
 	github.com/gin-gonic/gin v1.7.1
 	github.com/sirupsen/logrus v1.8.1
+	github.com/ugorji/go/codec v1.1.7
+	gopkg.in/yaml.v2 v2.2.8
 )
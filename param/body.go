@@ -0,0 +1,104 @@
+package param
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
+)
+
+const (
+	bodyKey        = "autoroute.param.body"
+	bodyLimiterKey = "autoroute.param.bodyLimiter"
+)
+
+// bodyLimiter 以http.MaxBytesReader限制请求体长度, 记录是否超过了限制,
+// 使json, 表单和multipart等读取请求体的方式共用同一个限制并返回相同的413异常
+type bodyLimiter struct {
+	io.ReadCloser
+	maxSize  int64
+	read     int64
+	exceeded bool
+}
+
+// Read 读取请求体, 超过最大长度时返回413异常
+func (limiter *bodyLimiter) Read(p []byte) (int, error) {
+	n, err := limiter.ReadCloser.Read(p)
+	limiter.read += int64(n)
+	if err != nil && err != io.EOF && limiter.read >= limiter.maxSize {
+		limiter.exceeded = true
+		return n, bodyTooLarge(limiter.maxSize)
+	}
+	return n, err
+}
+
+// limitBody 按照路由配置的最大长度包装请求体, 只会包装一次, 需要在读取请求体之前调用
+func limitBody(ctx *gin.Context) {
+	if _, ok := ctx.Get(bodyLimiterKey); ok {
+		return
+	}
+	maxSize := MaxBodySize(ctx)
+	if maxSize <= 0 || ctx.Request.Body == nil {
+		return
+	}
+	limiter := &bodyLimiter{
+		ReadCloser: http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize),
+		maxSize:    maxSize,
+	}
+	ctx.Request.Body = limiter
+	ctx.Set(bodyLimiterKey, limiter)
+}
+
+// bodyLimitError 读取请求体失败时, 如果是因为超过了最大长度, 返回413异常
+func bodyLimitError(ctx *gin.Context, err error) error {
+	if val, ok := ctx.Get(bodyLimiterKey); ok && val.(*bodyLimiter).exceeded {
+		return bodyTooLarge(val.(*bodyLimiter).maxSize)
+	}
+	return err
+}
+
+// ReadBody 读取请求体并缓存在gin.Context中, 之后的读取(包括ctx.Request.Body)都会得到相同的内容.
+// 请求体超过路由配置的最大长度时返回413异常
+func ReadBody(ctx *gin.Context) ([]byte, error) {
+	if val, ok := ctx.Get(bodyKey); ok {
+		return val.([]byte), nil
+	}
+	if ctx.Request.Body == nil {
+		return []byte{}, nil
+	}
+
+	if maxSize := MaxBodySize(ctx); maxSize > 0 && ctx.Request.ContentLength > maxSize {
+		return nil, bodyTooLarge(maxSize)
+	}
+
+	limitBody(ctx)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	ctx.Request.Body.Close()
+	if err != nil {
+		if limitErr := bodyLimitError(ctx, err); limitErr != err {
+			return nil, limitErr
+		}
+		return nil, exception.New(http.StatusBadRequest, fmt.Sprintf("read request body failed: %s", err.Error()), err)
+	}
+
+	ctx.Set(bodyKey, body)
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// MaxBodySize 获取当前路由允许的请求体最大长度, 0表示不限制
+func MaxBodySize(ctx *gin.Context) int64 {
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil {
+		return httpRequest.MaxBodySize
+	}
+	return 0
+}
+
+func bodyTooLarge(maxSize int64) *exception.HTTPException {
+	return exception.New(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds max size %d bytes", maxSize), nil)
+}
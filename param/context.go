@@ -0,0 +1,132 @@
+package param
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
+	"github.com/zhyeah/gin-autoreg/util"
+)
+
+// setContextValue 将中间件放入gin.Context中的值赋给字段, 类型可赋值时直接赋值, 数字类型之间会进行转换,
+// 字符串会转换为字段类型. 缺少必需的值时按'missing'标签或路由配置的code返回异常(默认500)
+func setContextValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	val, ok := ctx.Get(fieldInfo.Name)
+	if !ok || val == nil {
+		if fieldInfo.DefaultValue != "" {
+			return setContextString(fieldInfo, fieldInfo.DefaultValue)
+		}
+		if fieldInfo.MustHave {
+			return exception.New(missingContextCode(fieldInfo, ctx), fmt.Sprintf("context value '%s' is missing", fieldInfo.Name), nil)
+		}
+		return nil
+	}
+
+	value := reflect.ValueOf(val)
+	fieldType := fieldInfo.Type
+	switch {
+	case value.Type().AssignableTo(fieldType):
+		fieldInfo.Field.Set(value)
+	case fieldType.Kind() == reflect.Ptr && value.Type().AssignableTo(fieldType.Elem()):
+		ptr := reflect.New(fieldType.Elem())
+		ptr.Elem().Set(value)
+		fieldInfo.Field.Set(ptr)
+	case value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Type().AssignableTo(fieldType):
+		fieldInfo.Field.Set(value.Elem())
+	case util.IsNumberKind(value.Kind()) && util.IsNumberKind(fieldType.Kind()):
+		if !setNumberValue(fieldInfo.Field, value) {
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleType,
+				Message: fmt.Sprintf("field '%s' val '%v' overflows %s", fieldInfo.Name, val, fieldType),
+			}
+		}
+	case value.Kind() == reflect.String && isScalarKind(indirectType(fieldType).Kind()):
+		return setContextString(fieldInfo, value.String())
+	default:
+		return exception.New(http.StatusInternalServerError,
+			fmt.Sprintf("context value '%s' of type %s cannot be assigned to %s", fieldInfo.Name, value.Type(), fieldType), nil)
+	}
+	return nil
+}
+
+// setContextString 将字符串转换后赋值给字段
+func setContextString(fieldInfo *FieldInfo, str string) error {
+	if err := setStringValue(fieldInfo.Field, str); err != nil {
+		return &ValidationError{
+			Field:   fieldInfo.Name,
+			Rule:    RuleType,
+			Message: fmt.Sprintf("field '%s' val '%s' cannot convert to %s", fieldInfo.Name, str, fieldInfo.Type),
+		}
+	}
+	return nil
+}
+
+// missingContextCode 缺少context值时返回的code
+func missingContextCode(fieldInfo *FieldInfo, ctx *gin.Context) int {
+	if missing := fieldInfo.Tag.Get("missing"); missing != "" {
+		if code, err := util.ConvertStringToInt(missing); err == nil {
+			return code
+		}
+	}
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil && httpRequest.MissingContextCode != 0 {
+		return httpRequest.MissingContextCode
+	}
+	return http.StatusInternalServerError
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	return util.IsNumberKind(kind) || kind == reflect.Bool || kind == reflect.String
+}
+
+// setNumberValue 数字类型之间的转换, 溢出或丢失精度时返回false
+func setNumberValue(field reflect.Value, value reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch value.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if value.Uint() > uint64(1<<63-1) {
+				return false
+			}
+			n = int64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			if value.Float() != float64(int64(value.Float())) {
+				return false
+			}
+			n = int64(value.Float())
+		default:
+			n = value.Int()
+		}
+		if field.OverflowInt(n) {
+			return false
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if value.Int() < 0 {
+				return false
+			}
+			n = uint64(value.Int())
+		case reflect.Float32, reflect.Float64:
+			if value.Float() < 0 || value.Float() != float64(uint64(value.Float())) {
+				return false
+			}
+			n = uint64(value.Float())
+		default:
+			n = value.Uint()
+		}
+		if field.OverflowUint(n) {
+			return false
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		field.Set(value.Convert(field.Type()))
+	}
+	return true
+}
@@ -0,0 +1,282 @@
+package param
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// maxMultipartMemory 解析multipart表单时使用的内存大小, 与gin的默认值一致
+const maxMultipartMemory = 32 << 20
+
+// setStringValue 将字符串转换为目标类型后赋值
+func setStringValue(val reflect.Value, str string) error {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(str, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(str, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetUint(uintVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(str, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetFloat(floatVal)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		val.SetBool(boolVal)
+	case reflect.String:
+		val.SetString(str)
+	case reflect.Ptr:
+		elem := reflect.New(val.Type().Elem())
+		if err := setStringValue(elem.Elem(), str); err != nil {
+			return err
+		}
+		val.Set(elem)
+	case reflect.Interface:
+		if val.NumMethod() != 0 {
+			return fmt.Errorf("unsupported type %s", val.Type())
+		}
+		val.Set(reflect.ValueOf(str))
+	default:
+		return fmt.Errorf("unsupported type %s", val.Type())
+	}
+	return nil
+}
+
+// setStringsValue 将多个字符串赋值给切片, 非切片类型取第一个值
+func setStringsValue(val reflect.Value, strs []string) error {
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(val.Type(), len(strs), len(strs))
+		for i := range strs {
+			if err := setStringValue(slice.Index(i), strs[i]); err != nil {
+				return err
+			}
+		}
+		val.Set(slice)
+		return nil
+	}
+	if len(strs) == 0 {
+		return nil
+	}
+	return setStringValue(val, strs[0])
+}
+
+// bindURLValues 将url.Values绑定到结构体或map上, 没有form和json标签的字段按照命名策略取值
+func bindURLValues(values url.Values, val reflect.Value, naming NamingStrategy) error {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		return bindURLValues(values, val.Elem(), naming)
+	case reflect.Struct:
+		valType := val.Type()
+		for i := 0; i < valType.NumField(); i++ {
+			structField := valType.Field(i)
+			if structField.PkgPath != "" && !structField.Anonymous {
+				continue
+			}
+			if structField.Anonymous && indirectType(structField.Type).Kind() == reflect.Struct {
+				if err := bindURLValues(values, val.Field(i), naming); err != nil {
+					return err
+				}
+				continue
+			}
+			name := formFieldName(&structField, naming)
+			if name == "-" {
+				continue
+			}
+			strs, ok := values[name]
+			if !ok {
+				continue
+			}
+			if err := setStringsValue(val.Field(i), strs); err != nil {
+				return fmt.Errorf("field '%s' val '%s' cannot convert to %s", name, strings.Join(strs, ","), structField.Type)
+			}
+		}
+		return nil
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", val.Type().Key())
+		}
+		if val.IsNil() {
+			val.Set(reflect.MakeMap(val.Type()))
+		}
+		for k, strs := range values {
+			elem := reflect.New(val.Type().Elem()).Elem()
+			if err := setStringsValue(elem, strs); err != nil {
+				return fmt.Errorf("field '%s' val '%s' cannot convert to %s", k, strings.Join(strs, ","), val.Type().Elem())
+			}
+			val.SetMapIndex(reflect.ValueOf(k).Convert(val.Type().Key()), elem)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", val.Type())
+}
+
+// isURLValuesMap 判断字段是否为从query或form中收集的map, 如 filter[status]=active&filter[owner]=me
+func isURLValuesMap(typ reflect.Type, from string) bool {
+	if typ.Kind() != reflect.Map {
+		return false
+	}
+	for _, source := range strings.Split(from, ",") {
+		if source != FROM_QUERY && source != FROM_FORMDATA {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURLValuesMap 检查map字段的类型, key需要是字符串, value需要是可以由字符串转换的类型或其切片
+func checkURLValuesMap(typ reflect.Type) error {
+	if typ.Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s", typ.Key())
+	}
+	elemType := typ.Elem()
+	if elemType.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	switch elemType.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Chan, reflect.Func:
+		return fmt.Errorf("unsupported map value type %s", typ.Elem())
+	}
+	return nil
+}
+
+// setMapValue 收集 name[key] 或 name.key 形式的query或form参数, 按照map的value类型转换后赋值
+func setMapValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	locations := fieldInfo.Locations
+	if len(locations) == 0 {
+		locations = []*data.ParamLocation{{From: fieldInfo.From, Name: fieldInfo.Name}}
+	}
+	var collected url.Values
+	var location *data.ParamLocation
+	for _, location = range locations {
+		values, err := urlValuesOf(location.From, ctx)
+		if err != nil {
+			return err
+		}
+		if collected = collectMapValues(values, location.Name); len(collected) > 0 {
+			break
+		}
+	}
+	if len(collected) == 0 {
+		if fieldInfo.MustHave {
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but now it's empty", fieldInfo.Name),
+			}
+		}
+		return nil
+	}
+
+	mapVal := reflect.MakeMap(fieldInfo.Type)
+	errs := make(ValidationErrors, 0)
+	for _, key := range sortedValueKeys(collected) {
+		strs := collected[key]
+		elem := reflect.New(fieldInfo.Type.Elem()).Elem()
+		if err := setStringsValue(elem, strs); err != nil {
+			name := location.Name + "[" + key + "]"
+			errs = append(errs, &ValidationError{
+				Field:   name,
+				Rule:    RuleType,
+				Message: fmt.Sprintf("field '%s' val '%s' cannot convert to %s", name, strings.Join(strs, ","), fieldInfo.Type.Elem()),
+			})
+			continue
+		}
+		mapVal.SetMapIndex(reflect.ValueOf(key).Convert(fieldInfo.Type.Key()), elem)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	fieldInfo.Field.Set(mapVal)
+	return nil
+}
+
+// urlValuesOf 获取query或form的全部参数
+func urlValuesOf(from string, ctx *gin.Context) (url.Values, error) {
+	if from == FROM_QUERY {
+		return ctx.Request.URL.Query(), nil
+	}
+	limitBody(ctx)
+	// 非multipart的表单解析失败时ParseMultipartForm只返回ErrNotMultipart, 需要检查是否超过了最大长度
+	if err := ctx.Request.ParseMultipartForm(maxMultipartMemory); err != nil {
+		if limitErr := bodyLimitError(ctx, err); limitErr != err {
+			return nil, limitErr
+		}
+		if err != http.ErrNotMultipart {
+			return nil, err
+		}
+	}
+	return ctx.Request.PostForm, nil
+}
+
+// collectMapValues 收集以 name[ 或 name. 开头的参数, 返回去掉前缀后的key
+func collectMapValues(values url.Values, name string) url.Values {
+	ret := make(url.Values)
+	for key, strs := range values {
+		var subKey string
+		if strings.HasPrefix(key, name+"[") && strings.HasSuffix(key, "]") {
+			subKey = key[len(name)+1 : len(key)-1]
+		} else if strings.HasPrefix(key, name+".") {
+			subKey = key[len(name)+1:]
+		}
+		if subKey != "" {
+			ret[subKey] = append(ret[subKey], strs...)
+		}
+	}
+	return ret
+}
+
+func sortedValueKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formFieldName 获取表单字段名, 依次使用form标签, json标签和命名策略生成的字段名
+func formFieldName(structField *reflect.StructField, naming NamingStrategy) string {
+	for _, tagName := range []string{"form", "json"} {
+		if name := strings.Split(structField.Tag.Get(tagName), ",")[0]; name != "" {
+			return name
+		}
+	}
+	return naming.Name(structField.Name)
+}
+
+// indirectType 获取指针指向的类型
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
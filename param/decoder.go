@@ -0,0 +1,299 @@
+package param
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
+	"github.com/zhyeah/gin-autoreg/util"
+	"gopkg.in/yaml.v2"
+)
+
+// BodyDecoder 请求体解码器
+type BodyDecoder interface {
+	Decode(body []byte, objPtr interface{}) error
+}
+
+// BodyDecoderFunc 函数形式的请求体解码器
+type BodyDecoderFunc func(body []byte, objPtr interface{}) error
+
+// Decode 解码请求体
+func (f BodyDecoderFunc) Decode(body []byte, objPtr interface{}) error {
+	return f(body, objPtr)
+}
+
+// OptionsBodyDecoder 支持严格模式等选项的请求体解码器
+type OptionsBodyDecoder interface {
+	BodyDecoder
+	DecodeWithOptions(body []byte, objPtr interface{}, opts *util.AdaptOptions) error
+}
+
+// jsonBodyDecoder json请求体解码器, 默认会进行字符串和数字之间的宽松转换
+type jsonBodyDecoder struct{}
+
+// Decode 宽松模式解码
+func (jsonBodyDecoder) Decode(body []byte, objPtr interface{}) error {
+	return util.AdaptJSONForDTO(string(body), objPtr)
+}
+
+// DecodeWithOptions 按选项解码
+func (jsonBodyDecoder) DecodeWithOptions(body []byte, objPtr interface{}, opts *util.AdaptOptions) error {
+	return util.AdaptJSONForDTOWithOptions(string(body), objPtr, opts)
+}
+
+// formBodyDecoder 表单请求体解码器, 没有form和json标签的字段按照路由的命名策略取值
+type formBodyDecoder struct{}
+
+// Decode 使用默认的命名策略解码
+func (formBodyDecoder) Decode(body []byte, objPtr interface{}) error {
+	return formBodyDecoder{}.DecodeWithOptions(body, objPtr, nil)
+}
+
+// DecodeWithOptions 使用选项中的命名策略解码, 严格模式对表单无效
+func (formBodyDecoder) DecodeWithOptions(body []byte, objPtr interface{}, opts *util.AdaptOptions) error {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	var naming NamingStrategy
+	if opts != nil {
+		naming = opts.Naming
+	}
+	return bindURLValues(values, reflect.ValueOf(objPtr), naming)
+}
+
+var bodyDecodersLock sync.RWMutex
+var bodyDecoders = map[string]BodyDecoder{}
+
+func init() {
+	jsonDecoder := jsonBodyDecoder{}
+	RegisterBodyDecoder("application/json", jsonDecoder)
+	RegisterBodyDecoder("text/json", jsonDecoder)
+
+	xmlDecoder := BodyDecoderFunc(xml.Unmarshal)
+	RegisterBodyDecoder("application/xml", xmlDecoder)
+	RegisterBodyDecoder("text/xml", xmlDecoder)
+
+	yamlDecoder := BodyDecoderFunc(yaml.Unmarshal)
+	RegisterBodyDecoder("application/x-yaml", yamlDecoder)
+	RegisterBodyDecoder("application/yaml", yamlDecoder)
+	RegisterBodyDecoder("text/yaml", yamlDecoder)
+
+	RegisterBodyDecoder("application/x-www-form-urlencoded", formBodyDecoder{})
+
+	msgpackHandle := &codec.MsgpackHandle{}
+	msgpackHandle.RawToString = true
+	msgpackDecoder := BodyDecoderFunc(func(body []byte, objPtr interface{}) error {
+		return codec.NewDecoderBytes(body, msgpackHandle).Decode(objPtr)
+	})
+	RegisterBodyDecoder("application/msgpack", msgpackDecoder)
+	RegisterBodyDecoder("application/x-msgpack", msgpackDecoder)
+}
+
+// RegisterBodyDecoder 注册content type对应的请求体解码器, 已存在的会被覆盖
+func RegisterBodyDecoder(contentType string, decoder BodyDecoder) {
+	bodyDecodersLock.Lock()
+	defer bodyDecodersLock.Unlock()
+	bodyDecoders[strings.ToLower(contentType)] = decoder
+}
+
+// GetBodyDecoder 获取content type对应的请求体解码器, 未指定content type时使用json
+func GetBodyDecoder(contentType string) (BodyDecoder, bool) {
+	mediaType := "application/json"
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, false
+		}
+		mediaType = parsed
+	}
+
+	bodyDecodersLock.RLock()
+	defer bodyDecodersLock.RUnlock()
+	decoder, ok := bodyDecoders[mediaType]
+	return decoder, ok
+}
+
+// setBodyValue 根据请求的content type解码请求体并赋值给字段,
+// 字段有'path'标签时只解码json请求体中对应的部分
+func setBodyValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	contentType := ctx.GetHeader("Content-Type")
+	decoder, ok := GetBodyDecoder(contentType)
+	if !ok {
+		return exception.New(http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type '%s'", contentType), nil)
+	}
+
+	body, err := ReadBody(ctx)
+	if err != nil {
+		return err
+	}
+	// 空的请求体没有可以解码的值, 必需的字段报告为缺少值
+	if len(bytes.TrimSpace(body)) == 0 {
+		if fieldInfo.MustHave {
+			name := fieldInfo.Name
+			if path := fieldInfo.Tag.Get("path"); path != "" {
+				name = path
+			}
+			return &ValidationError{
+				Field:   name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but request body is empty", name),
+			}
+		}
+		return nil
+	}
+
+	if path := fieldInfo.Tag.Get("path"); path != "" {
+		if !isJSONContentType(contentType) {
+			return exception.New(http.StatusUnsupportedMediaType, fmt.Sprintf("field '%s' with path '%s' only supports json body", fieldInfo.FieldName, path), nil)
+		}
+		subBody, found, err := extractJSONPath(body, path)
+		if err != nil {
+			return err
+		}
+		if !found {
+			if fieldInfo.MustHave {
+				return &ValidationError{
+					Field:   path,
+					Rule:    RuleRequired,
+					Message: fmt.Sprintf("field '%s' must have val, but now it's empty", path),
+				}
+			}
+			return nil
+		}
+		body = subBody
+	}
+
+	// 指针类型解码到新建的对象上, 这里手动强制适配
+	valType := fieldInfo.Type
+	if valType.Kind() == reflect.Ptr {
+		valType = valType.Elem()
+	}
+	val := reflect.New(valType)
+	if err := decodeWithOptions(ctx, decoder, body, val.Interface()); err != nil {
+		return convertJSONPathErrors(err, fieldInfo.Tag.Get("path"))
+	}
+	if fieldInfo.Type.Kind() == reflect.Ptr {
+		fieldInfo.Field.Set(val)
+	} else {
+		fieldInfo.Field.Set(val.Elem())
+	}
+	return nil
+}
+
+// decodeWithOptions 使用路由配置的严格模式选项和命名策略解码
+func decodeWithOptions(ctx *gin.Context, decoder BodyDecoder, body []byte, objPtr interface{}) error {
+	optionsDecoder, ok := decoder.(OptionsBodyDecoder)
+	if !ok {
+		return decoder.Decode(body, objPtr)
+	}
+	opts := &util.AdaptOptions{Naming: NamingOf(ctx).Name}
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil {
+		opts.Strict = httpRequest.StrictBody
+		opts.Coerce = httpRequest.CoerceBody
+	}
+	return optionsDecoder.DecodeWithOptions(body, objPtr, opts)
+}
+
+// convertJSONPathErrors 将json路径错误转换为字段校验错误
+func convertJSONPathErrors(err error, root string) error {
+	pathErrs, ok := err.(util.JSONPathErrors)
+	if !ok {
+		return err
+	}
+	errs := make(ValidationErrors, 0, len(pathErrs))
+	for _, pathErr := range pathErrs {
+		field := pathErr.Path
+		message := pathErr.Message
+		if root != "" {
+			field = joinJSONPath(root, strings.TrimPrefix(field, "$"))
+			message = field + strings.TrimPrefix(message, pathErr.Path)
+		}
+		errs = append(errs, &ValidationError{
+			Field:   field,
+			Rule:    pathErr.Reason,
+			Message: message,
+		})
+	}
+	return errs
+}
+
+// joinJSONPath 拼接'path'标签和其中的json路径, 数组下标直接拼接, 与校验错误的路径一致
+func joinJSONPath(root string, path string) string {
+	if strings.HasPrefix(path, "[") {
+		return root + path
+	}
+	return joinParamKey(root, path)
+}
+
+// isJSONContentType 判断是否为json格式的content type
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// extractJSONPath 获取json中path对应的子文档, path形如 "data.items" 或 "data.items[0].name"
+func extractJSONPath(body []byte, path string) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, false, err
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		indexes := make([]int, 0)
+		if idx := strings.Index(segment, "["); idx >= 0 {
+			key = segment[:idx]
+			for _, item := range strings.Split(segment[idx+1:], "[") {
+				index, err := strconv.Atoi(strings.TrimSuffix(item, "]"))
+				if err != nil {
+					return nil, false, fmt.Errorf("invalid body path '%s'", path)
+				}
+				indexes = append(indexes, index)
+			}
+		}
+
+		if key != "" {
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+			if doc, ok = obj[key]; !ok {
+				return nil, false, nil
+			}
+		}
+		for _, index := range indexes {
+			arr, ok := doc.([]interface{})
+			if !ok || index < 0 || index >= len(arr) {
+				return nil, false, nil
+			}
+			doc = arr[index]
+		}
+	}
+
+	if doc == nil {
+		return nil, false, nil
+	}
+	subBody, err := json.Marshal(doc)
+	return subBody, true, err
+}
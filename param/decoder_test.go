@@ -0,0 +1,102 @@
+package param
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type decodeItem struct {
+	Age int `json:"age" validate:"min=1"`
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newBodyContext(body string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	return ctx
+}
+
+func TestSetBodyValueErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		path   string
+		target interface{}
+		must   bool
+		// fields 期望出错的字段及规则, 为空时期望成功
+		fields []string
+	}{
+		{name: "index of path", body: `{"data":{"items":["a"]}}`, path: "data.items", target: []int{}, must: true, fields: []string{"data.items[0]:type"}},
+		{name: "field in index of path", body: `{"data":{"items":[{"age":"x"}]}}`, path: "data.items", target: []decodeItem{}, must: true, fields: []string{"data.items[0].age:type"}},
+		{name: "field of path", body: `{"data":{"age":"x"}}`, path: "data", target: decodeItem{}, must: true, fields: []string{"data.age:type"}},
+		{name: "mismatch at path", body: `{"data":{"items":{}}}`, path: "data.items", target: []int{}, must: true, fields: []string{"data.items:type"}},
+		{name: "without path", body: `{"age":"x"}`, target: decodeItem{}, must: true, fields: []string{"age:type"}},
+		{name: "missing path", body: `{"data":{}}`, path: "data.items", target: []int{}, must: true, fields: []string{"data.items:required"}},
+		{name: "empty body", body: "", target: decodeItem{}, must: true, fields: []string{"b:required"}},
+		{name: "blank body", body: " \n", target: decodeItem{}, must: true, fields: []string{"b:required"}},
+		{name: "empty body with path", body: "", path: "data.items", target: []int{}, must: true, fields: []string{"data.items:required"}},
+		{name: "empty optional body", body: "", target: decodeItem{}},
+		{name: "valid", body: `{"data":{"items":[1,"2"]}}`, path: "data.items", target: []int{}, must: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			typ := reflect.TypeOf(c.target)
+			tag := `from:"body"`
+			if c.path != "" {
+				tag += ` path:"` + c.path + `"`
+			}
+			err := setBodyValue(&FieldInfo{
+				Field:    reflect.New(typ).Elem(),
+				Name:     "b",
+				From:     FROM_BODY,
+				MustHave: c.must,
+				Type:     typ,
+				Tag:      reflect.StructTag(tag),
+			}, newBodyContext(c.body))
+			if len(c.fields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if got := validationFields(t, err); !reflect.DeepEqual(got, c.fields) {
+				t.Fatalf("got error fields %v, want %v: %v", got, c.fields, err)
+			}
+		})
+	}
+}
+
+// TestBodyErrorPathsMatchValidation 解码错误和标签校验错误使用相同的路径格式
+func TestBodyErrorPathsMatchValidation(t *testing.T) {
+	errs := make(ValidationErrors, 0)
+	validateValue(reflect.ValueOf([]decodeItem{{Age: 0}}), "data.items", nil, &errs)
+	if got := validationFields(t, errs); !reflect.DeepEqual(got, []string{"data.items[0].age:min"}) {
+		t.Fatalf("got validation fields %v", got)
+	}
+}
+
+func validationFields(t *testing.T, err error) []string {
+	var errs ValidationErrors
+	switch e := err.(type) {
+	case *ValidationError:
+		errs = ValidationErrors{e}
+	case ValidationErrors:
+		errs = e
+	default:
+		t.Fatalf("want validation errors, got %v", err)
+	}
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field+":"+e.Rule)
+	}
+	return fields
+}
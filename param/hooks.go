@@ -0,0 +1,58 @@
+package param
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/zhyeah/gin-autoreg/exception"
+)
+
+// Defaulter 请求结构体绑定后, 用于填充需要计算的默认值
+type Defaulter interface {
+	Defaults()
+}
+
+// Normalizer 请求结构体绑定后, 用于规范化字段值, 如去除空白, 转换大小写
+type Normalizer interface {
+	Normalize()
+}
+
+// Validator 请求结构体通过标签校验后, 用于自定义校验, 如多个字段间的关系
+type Validator interface {
+	Validate() error
+}
+
+// resolveHooks 依次调用请求结构体的Defaults, Normalize, 标签校验和Validate.
+// Validate返回的HTTPException保留其code, 其他错误作为400返回
+func resolveHooks(paramInstancePtr reflect.Value, naming NamingStrategy, errs *ValidationErrors) error {
+	instance := paramInstancePtr.Interface()
+	if defaulter, ok := instance.(Defaulter); ok {
+		defaulter.Defaults()
+	}
+	if normalizer, ok := instance.(Normalizer); ok {
+		normalizer.Normalize()
+	}
+
+	validateStructFields(paramInstancePtr.Elem(), naming, errs)
+	if len(*errs) > 0 {
+		return nil
+	}
+
+	validator, ok := instance.(Validator)
+	if !ok {
+		return nil
+	}
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *exception.HTTPException:
+		return e
+	case ValidationErrors:
+		return e.ToHTTPException()
+	case *ValidationError:
+		return ValidationErrors{e}.ToHTTPException()
+	}
+	return exception.New(http.StatusBadRequest, err.Error(), err)
+}
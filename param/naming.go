@@ -0,0 +1,37 @@
+package param
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/util"
+)
+
+// NamingStrategy 字段没有'field'标签时, 由结构体字段名生成参数名的策略
+type NamingStrategy func(fieldName string) string
+
+// 内置的命名策略
+var (
+	// LowerCamelCase 首字母小写, 如 UserID -> userID, 为默认策略
+	LowerCamelCase NamingStrategy = util.FirstToLower
+	// SnakeCase 下划线形式, 如 UserID -> user_id
+	SnakeCase NamingStrategy = util.ToSnakeCase
+	// KebabCase 中划线形式, 如 UserID -> user-id
+	KebabCase NamingStrategy = util.ToKebabCase
+)
+
+// Name 按照命名策略生成字段的参数名, 策略为nil时使用默认策略
+func (strategy NamingStrategy) Name(fieldName string) string {
+	if strategy == nil {
+		return util.FirstToLower(fieldName)
+	}
+	return strategy(fieldName)
+}
+
+// NamingOf 当前请求的路由使用的命名策略, 作用于query, path, form, header参数, 请求体的字段以及错误信息.
+// 路由没有设置时返回nil, 即默认策略
+func NamingOf(ctx *gin.Context) NamingStrategy {
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil {
+		return httpRequest.Naming
+	}
+	return nil
+}
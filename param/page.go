@@ -0,0 +1,222 @@
+package param
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+// 分页参数的名称
+const (
+	PageKeyPage   = "page"
+	PageKeySize   = "size"
+	PageKeyCursor = "cursor"
+	PageKeyLimit  = "limit"
+)
+
+// 默认的分页大小和最大值
+const (
+	DefaultPageSize    = 20
+	DefaultMaxPageSize = 100
+)
+
+var pageType = reflect.TypeOf(Page{})
+
+// Page 分页参数, 从query或form中读取 page/size, 或者 cursor/limit.
+// 默认大小和最大值通过'page'标签声明, 如 page:"size=20,max=100"
+type Page struct {
+	// Number 页码, 从1开始, 游标分页时为0
+	Number int
+	Size   int
+	Cursor string
+}
+
+// IsCursor 是否为游标分页
+func (page *Page) IsCursor() bool {
+	return page.Number == 0
+}
+
+// Offset 页码分页的偏移量
+func (page *Page) Offset() int {
+	if page.Number <= 0 {
+		return 0
+	}
+	return (page.Number - 1) * page.Size
+}
+
+// Limit 每页的数量
+func (page *Page) Limit() int {
+	return page.Size
+}
+
+// Response 生成分页响应, 游标分页时还需要设置NextCursor
+func (page *Page) Response(total int64, items interface{}) *vo.PagedResponse {
+	return &vo.PagedResponse{
+		Total: total,
+		Page:  page.Number,
+		Size:  page.Size,
+		Items: items,
+	}
+}
+
+// pageOptions 'page'标签声明的分页选项
+type pageOptions struct {
+	size int
+	max  int
+}
+
+// isPageType 判断字段是否为Page或*Page类型
+func isPageType(typ reflect.Type) bool {
+	return typ == pageType || (typ.Kind() == reflect.Ptr && typ.Elem() == pageType)
+}
+
+// parsePageOptions 解析'page'标签
+func parsePageOptions(tag string) (*pageOptions, error) {
+	opts := &pageOptions{size: DefaultPageSize, max: DefaultMaxPageSize}
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid page option '%s'", item)
+		}
+		val, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || val <= 0 {
+			return nil, fmt.Errorf("invalid page option '%s'", item)
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "size":
+			opts.size = val
+		case "max":
+			opts.max = val
+		default:
+			return nil, fmt.Errorf("unknown page option '%s'", item)
+		}
+	}
+	if opts.size > opts.max {
+		return nil, fmt.Errorf("default page size %d exceeds max %d", opts.size, opts.max)
+	}
+	return opts, nil
+}
+
+// checkPageField 在注册路由时检查分页字段, 分页参数的名称是固定的, 不能通过'field'标签指定
+func checkPageField(structField *reflect.StructField, from string) error {
+	if !isPageType(structField.Type) {
+		return nil
+	}
+	if from != FROM_QUERY && from != FROM_FORMDATA {
+		return fmt.Errorf("page field should come from query or form")
+	}
+	if _, ok := structField.Tag.Lookup("field"); ok {
+		return fmt.Errorf("page field binds the fixed params %s, %s, %s and %s, it cannot have a 'field' tag",
+			PageKeyPage, PageKeySize, PageKeyCursor, PageKeyLimit)
+	}
+	_, err := parsePageOptions(structField.Tag.Get("page"))
+	return err
+}
+
+// setPageValue 绑定分页参数, 有cursor参数时为游标分页, 否则为页码分页
+func setPageValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	opts, err := parsePageOptions(fieldInfo.Tag.Get("page"))
+	if err != nil {
+		return err
+	}
+	values, err := urlValuesOf(fieldInfo.From, ctx)
+	if err != nil {
+		return err
+	}
+
+	page := &Page{Size: opts.size}
+	errs := make(ValidationErrors, 0)
+	if cursor, ok := values[PageKeyCursor]; ok {
+		page.Cursor = cursor[0]
+	} else {
+		page.Number = 1
+		if err := parsePageInt(values, PageKeyPage, 1, 0, &page.Number); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, key := range []string{PageKeySize, PageKeyLimit} {
+		if _, ok := values[key]; ok {
+			if err := parsePageInt(values, key, 1, opts.max, &page.Size); err != nil {
+				errs = append(errs, err)
+			}
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if fieldInfo.Type.Kind() == reflect.Ptr {
+		fieldInfo.Field.Set(reflect.ValueOf(page))
+	} else {
+		fieldInfo.Field.Set(reflect.ValueOf(*page))
+	}
+	return nil
+}
+
+// parsePageInt 解析分页参数中的整数, max为0时不限制最大值
+func parsePageInt(values url.Values, key string, min int, max int, target *int) *ValidationError {
+	str := values.Get(key)
+	if str == "" {
+		return nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil {
+		return &ValidationError{Field: key, Rule: RuleType, Message: fmt.Sprintf("field '%s' val '%s' cannot convert to int", key, str)}
+	}
+	if val < min {
+		return &ValidationError{Field: key, Rule: RuleMin, Message: fmt.Sprintf("field '%s' must be at least %d", key, min)}
+	}
+	if max > 0 && val > max {
+		return &ValidationError{Field: key, Rule: RuleMax, Message: fmt.Sprintf("field '%s' must be at most %d", key, max)}
+	}
+	*target = val
+	return nil
+}
+
+// PageLinks 根据分页响应和当前请求的url生成Link响应头, 页码分页包括first, prev, next, last, 游标分页只有next
+func PageLinks(reqURL *url.URL, resp *vo.PagedResponse) string {
+	links := make([]string, 0)
+	link := func(rel string, params map[string]string) {
+		query := reqURL.Query()
+		for key, val := range params {
+			query.Set(key, val)
+		}
+		u := *reqURL
+		u.Scheme, u.Host, u.RawQuery = "", "", query.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel))
+	}
+
+	if resp.Page <= 0 {
+		if resp.NextCursor != "" {
+			link("next", map[string]string{PageKeyCursor: resp.NextCursor})
+		}
+		return strings.Join(links, ", ")
+	}
+	if resp.Size <= 0 {
+		return ""
+	}
+	last := int((resp.Total + int64(resp.Size) - 1) / int64(resp.Size))
+	if last < 1 {
+		last = 1
+	}
+	size := strconv.Itoa(resp.Size)
+	link("first", map[string]string{PageKeyPage: "1", PageKeySize: size})
+	if resp.Page > 1 {
+		link("prev", map[string]string{PageKeyPage: strconv.Itoa(resp.Page - 1), PageKeySize: size})
+	}
+	if resp.Page < last {
+		link("next", map[string]string{PageKeyPage: strconv.Itoa(resp.Page + 1), PageKeySize: size})
+	}
+	link("last", map[string]string{PageKeyPage: strconv.Itoa(last), PageKeySize: size})
+	return strings.Join(links, ", ")
+}
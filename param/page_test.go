@@ -0,0 +1,132 @@
+package param
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+func TestPageLinks(t *testing.T) {
+	reqURL, _ := url.Parse("http://example.com/users?q=x&page=2&size=10")
+	cases := []struct {
+		name string
+		resp *vo.PagedResponse
+		want string
+	}{
+		{
+			name: "first page",
+			resp: &vo.PagedResponse{Total: 25, Page: 1, Size: 10},
+			want: `</users?page=1&q=x&size=10>; rel="first", </users?page=2&q=x&size=10>; rel="next", </users?page=3&q=x&size=10>; rel="last"`,
+		},
+		{
+			name: "middle page",
+			resp: &vo.PagedResponse{Total: 25, Page: 2, Size: 10},
+			want: `</users?page=1&q=x&size=10>; rel="first", </users?page=1&q=x&size=10>; rel="prev", </users?page=3&q=x&size=10>; rel="next", </users?page=3&q=x&size=10>; rel="last"`,
+		},
+		{
+			name: "last page",
+			resp: &vo.PagedResponse{Total: 30, Page: 3, Size: 10},
+			want: `</users?page=1&q=x&size=10>; rel="first", </users?page=2&q=x&size=10>; rel="prev", </users?page=3&q=x&size=10>; rel="last"`,
+		},
+		{
+			name: "no items",
+			resp: &vo.PagedResponse{Total: 0, Page: 1, Size: 10},
+			want: `</users?page=1&q=x&size=10>; rel="first", </users?page=1&q=x&size=10>; rel="last"`,
+		},
+		{
+			name: "cursor",
+			resp: &vo.PagedResponse{Total: 25, NextCursor: "abc"},
+			want: `</users?cursor=abc&page=2&q=x&size=10>; rel="next"`,
+		},
+		{
+			name: "last cursor",
+			resp: &vo.PagedResponse{Total: 25},
+			want: "",
+		},
+		{
+			name: "no size",
+			resp: &vo.PagedResponse{Total: 25, Page: 1},
+			want: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := PageLinks(reqURL, c.resp); got != c.want {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSetPageValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		tag   string
+		want  Page
+		// fields 期望出错的字段及规则, 为空时期望成功
+		fields []string
+	}{
+		{name: "defaults", query: "", want: Page{Number: 1, Size: DefaultPageSize}},
+		{name: "tag defaults", query: "", tag: "size=10,max=50", want: Page{Number: 1, Size: 10}},
+		{name: "page and size", query: "page=3&size=50", tag: "size=10,max=50", want: Page{Number: 3, Size: 50}},
+		{name: "cursor and limit", query: "cursor=abc&limit=5", want: Page{Cursor: "abc", Size: 5}},
+		{name: "cursor ignores page", query: "cursor=abc&page=x", want: Page{Cursor: "abc", Size: DefaultPageSize}},
+		{name: "size above max", query: "size=51", tag: "size=10,max=50", fields: []string{"size:max"}},
+		{name: "limit above max", query: "cursor=abc&limit=101", fields: []string{"limit:max"}},
+		{name: "size below min", query: "size=0", fields: []string{"size:min"}},
+		{name: "page below min", query: "page=0", fields: []string{"page:min"}},
+		{name: "invalid page and size", query: "page=x&size=y", fields: []string{"page:type", "size:type"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest("GET", "/?"+c.query, nil)
+			page := &Page{}
+			err := setPageValue(&FieldInfo{
+				Field: reflect.ValueOf(&page).Elem(),
+				Name:  "page",
+				From:  FROM_QUERY,
+				Type:  reflect.TypeOf(page),
+				Tag:   reflect.StructTag(`page:"` + c.tag + `"`),
+			}, ctx)
+			if len(c.fields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if *page != c.want {
+					t.Fatalf("got %+v, want %+v", *page, c.want)
+				}
+				return
+			}
+			if got := validationFields(t, err); !reflect.DeepEqual(got, c.fields) {
+				t.Fatalf("got error fields %v, want %v: %v", got, c.fields, err)
+			}
+		})
+	}
+}
+
+func TestParsePageOptions(t *testing.T) {
+	cases := []struct {
+		tag string
+		err string
+	}{
+		{tag: "size=10,max=50"},
+		{tag: "size=60,max=50", err: "default page size 60 exceeds max 50"},
+		{tag: "size=0", err: "invalid page option 'size=0'"},
+		{tag: "size", err: "invalid page option 'size'"},
+		{tag: "min=1", err: "unknown page option 'min=1'"},
+	}
+	for _, c := range cases {
+		_, err := parsePageOptions(c.tag)
+		if c.err == "" && err != nil || c.err != "" && (err == nil || err.Error() != c.err) {
+			t.Fatalf("tag %q: got error %v, want %q", c.tag, err, c.err)
+		}
+	}
+}
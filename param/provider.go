@@ -0,0 +1,85 @@
+package param
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/exception"
+)
+
+var (
+	ginContextType = reflect.TypeOf((*gin.Context)(nil))
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+var providersLock sync.RWMutex
+var providers = map[reflect.Type]reflect.Value{}
+
+// RegisterProvider 注册handler参数的提供者, provider形如 func(*gin.Context) (T, error),
+// handler中类型为T的参数会在每次请求时调用provider获得
+func RegisterProvider(provider interface{}) error {
+	providerValue := reflect.ValueOf(provider)
+	providerType := providerValue.Type()
+	if providerType.Kind() != reflect.Func || providerType.NumIn() != 1 || providerType.In(0) != ginContextType ||
+		providerType.NumOut() != 2 || providerType.Out(1) != errorType {
+		return fmt.Errorf("provider should be like 'func(*gin.Context) (T, error)', but got %s", providerType)
+	}
+
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providers[providerType.Out(0)] = providerValue
+	return nil
+}
+
+// getProvider 获取类型对应的提供者
+func getProvider(typ reflect.Type) (reflect.Value, bool) {
+	providersLock.RLock()
+	defer providersLock.RUnlock()
+	provider, ok := providers[typ]
+	return provider, ok
+}
+
+// resolveProvided 调用提供者获得参数值, 返回的HTTPException保留其code, 其他错误作为500返回
+func resolveProvided(provider reflect.Value, ctx *gin.Context) (interface{}, error) {
+	rets := provider.Call([]reflect.Value{reflect.ValueOf(ctx)})
+	if err, ok := rets[1].Interface().(error); ok && err != nil {
+		if httpException, ok := err.(*exception.HTTPException); ok {
+			return nil, httpException
+		}
+		return nil, exception.New(http.StatusInternalServerError, err.Error(), err)
+	}
+	return rets[0].Interface(), nil
+}
+
+// requestStructType 如果参数是需要绑定的请求结构体(或其指针), 返回结构体类型
+func requestStructType(inType reflect.Type) (reflect.Type, bool) {
+	if inType == ginContextType {
+		return nil, false
+	}
+	if _, ok := getProvider(inType); ok {
+		return nil, false
+	}
+	if inType.Kind() == reflect.Ptr {
+		inType = inType.Elem()
+	}
+	return inType, inType.Kind() == reflect.Struct
+}
+
+// checkParamType 检查handler参数类型是否支持
+func checkParamType(inType reflect.Type) error {
+	if inType == ginContextType || inType == contextType {
+		return nil
+	}
+	if _, ok := requestStructType(inType); ok {
+		return nil
+	}
+	if _, ok := getProvider(inType); ok {
+		return nil
+	}
+	return fmt.Errorf("unsupport controller param type %s, register a provider for it", inType)
+}
@@ -0,0 +1,365 @@
+package param
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/zhyeah/gin-autoreg/data"
+)
+
+// 过滤条件的操作符
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpGt   = "gt"
+	OpGe   = "ge"
+	OpLt   = "lt"
+	OpLe   = "le"
+	OpLike = "like"
+	OpIn   = "in"
+)
+
+// filterOperators 支持的全部操作符, 字段没有指定操作符时允许全部
+var filterOperators = []string{OpEq, OpNe, OpGt, OpGe, OpLt, OpLe, OpLike, OpIn}
+
+var (
+	sortType   = reflect.TypeOf(Sort(nil))
+	filterType = reflect.TypeOf(Filter(nil))
+)
+
+// SortField 单个排序字段
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort 排序参数, 如 sort=-createdAt,name, '-'前缀表示降序.
+// 允许排序的字段通过'sortable'标签声明, 如 sortable:"createdAt,name"
+type Sort []*SortField
+
+// FilterCondition 单个过滤条件, in操作符的多个值保存在Values中, 其他操作符只有一个值
+type FilterCondition struct {
+	Field    string
+	Operator string
+	Values   []string
+}
+
+// Value 条件的第一个值
+func (cond *FilterCondition) Value() string {
+	if len(cond.Values) == 0 {
+		return ""
+	}
+	return cond.Values[0]
+}
+
+// Filter 过滤参数, 多个条件以and连接, 如 filter=age gt 18 and name eq 'x' and status in (1,2).
+// 允许过滤的字段和操作符通过'filterable'标签声明, 如 filterable:"age:gt|lt,name:eq|like,status",
+// 没有指定操作符的字段允许全部操作符
+type Filter []*FilterCondition
+
+// isQueryDSLType 判断字段是否为Sort或Filter类型
+func isQueryDSLType(typ reflect.Type) bool {
+	return typ == sortType || typ == filterType
+}
+
+// parseSortable 解析'sortable'标签
+func parseSortable(tag string) []string {
+	fields := make([]string, 0)
+	for _, field := range strings.Split(tag, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// parseFilterable 解析'filterable'标签, 返回字段允许的操作符
+func parseFilterable(tag string) (map[string][]string, error) {
+	ret := make(map[string][]string)
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		field, ops := item, ""
+		if i := strings.Index(item, ":"); i >= 0 {
+			field, ops = strings.TrimSpace(item[:i]), item[i+1:]
+		}
+		if ops == "" {
+			ret[field] = filterOperators
+			continue
+		}
+		ret[field] = make([]string, 0)
+		for _, op := range strings.Split(ops, "|") {
+			op = strings.ToLower(strings.TrimSpace(op))
+			if !containsString(filterOperators, op) {
+				return nil, fmt.Errorf("unknown filter operator '%s' of field '%s'", op, field)
+			}
+			ret[field] = append(ret[field], op)
+		}
+	}
+	return ret, nil
+}
+
+// checkQueryDSLTag 在注册路由时检查Sort和Filter字段的白名单标签
+func checkQueryDSLTag(structField *reflect.StructField) error {
+	switch structField.Type {
+	case sortType:
+		if len(parseSortable(structField.Tag.Get("sortable"))) == 0 {
+			return fmt.Errorf("sort field needs a 'sortable' tag")
+		}
+	case filterType:
+		filterable, err := parseFilterable(structField.Tag.Get("filterable"))
+		if err != nil {
+			return err
+		}
+		if len(filterable) == 0 {
+			return fmt.Errorf("filter field needs a 'filterable' tag")
+		}
+	}
+	return nil
+}
+
+// queryDSLInfo 将白名单记录到路由元数据中
+func queryDSLInfo(structField *reflect.StructField, info *data.ParamInfo) {
+	switch structField.Type {
+	case sortType:
+		info.Sortable = parseSortable(structField.Tag.Get("sortable"))
+	case filterType:
+		info.Filterable, _ = parseFilterable(structField.Tag.Get("filterable"))
+	}
+}
+
+// setQueryDSLValue 解析并校验Sort或Filter字段
+func setQueryDSLValue(fieldInfo *FieldInfo, valStr string) error {
+	if strings.TrimSpace(valStr) == "" {
+		if fieldInfo.MustHave {
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but now it's empty", fieldInfo.Name),
+			}
+		}
+		return nil
+	}
+
+	if fieldInfo.Type == sortType {
+		val, err := parseSort(valStr, parseSortable(fieldInfo.Tag.Get("sortable")))
+		if err != nil {
+			return &ValidationError{Field: fieldInfo.Name, Rule: RuleSort, Message: fmt.Sprintf("field '%s': %s", fieldInfo.Name, err.Error())}
+		}
+		fieldInfo.Field.Set(reflect.ValueOf(val))
+		return nil
+	}
+
+	filterable, err := parseFilterable(fieldInfo.Tag.Get("filterable"))
+	if err != nil {
+		return err
+	}
+	val, err := parseFilter(valStr, filterable)
+	if err != nil {
+		return &ValidationError{Field: fieldInfo.Name, Rule: RuleFilter, Message: fmt.Sprintf("field '%s': %s", fieldInfo.Name, err.Error())}
+	}
+	fieldInfo.Field.Set(reflect.ValueOf(val))
+	return nil
+}
+
+// parseSort 解析排序表达式
+func parseSort(str string, sortable []string) (Sort, error) {
+	ret := make(Sort, 0)
+	seen := make(map[string]bool)
+	for i, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		sortField := &SortField{Field: item}
+		if strings.HasPrefix(item, "-") {
+			sortField.Field, sortField.Desc = item[1:], true
+		} else if strings.HasPrefix(item, "+") {
+			sortField.Field = item[1:]
+		}
+		if sortField.Field == "" {
+			return nil, fmt.Errorf("empty sort field at index %d", i)
+		}
+		if !containsString(sortable, sortField.Field) {
+			return nil, fmt.Errorf("cannot sort by '%s', allowed fields are %s", sortField.Field, strings.Join(sortable, ", "))
+		}
+		if seen[sortField.Field] {
+			return nil, fmt.Errorf("duplicate sort field '%s'", sortField.Field)
+		}
+		seen[sortField.Field] = true
+		ret = append(ret, sortField)
+	}
+	return ret, nil
+}
+
+// filterToken 过滤表达式的词法单元, pos为从1开始的字符位置
+type filterToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+func (token *filterToken) describe() string {
+	if token == nil {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s' at position %d", token.text, token.pos)
+}
+
+// tokenizeFilter 拆分过滤表达式, 值可以使用单引号, 引号内连续的两个单引号表示一个单引号
+func tokenizeFilter(str string) ([]*filterToken, error) {
+	runes := []rune(str)
+	tokens := make([]*filterToken, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, &filterToken{text: string(r), pos: i + 1})
+			i++
+		case r == '\'':
+			start := i
+			var sb strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			tokens = append(tokens, &filterToken{text: sb.String(), quoted: true, pos: start + 1})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("(),'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, &filterToken{text: string(runes[start:i]), pos: start + 1})
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser 过滤表达式的语法解析器
+type filterParser struct {
+	tokens []*filterToken
+	index  int
+}
+
+func (parser *filterParser) next() *filterToken {
+	if parser.index >= len(parser.tokens) {
+		return nil
+	}
+	token := parser.tokens[parser.index]
+	parser.index++
+	return token
+}
+
+// value 读取一个值, 不加引号的值不能是括号或逗号
+func (parser *filterParser) value() (string, error) {
+	token := parser.next()
+	if token == nil || (!token.quoted && strings.Contains("(),", token.text)) {
+		return "", fmt.Errorf("expected a value, got %s", token.describe())
+	}
+	return token.text, nil
+}
+
+// parseFilter 解析过滤表达式并按照白名单校验
+func parseFilter(str string, filterable map[string][]string) (Filter, error) {
+	tokens, err := tokenizeFilter(str)
+	if err != nil {
+		return nil, err
+	}
+	parser := &filterParser{tokens: tokens}
+	ret := make(Filter, 0)
+	for {
+		fieldToken := parser.next()
+		if fieldToken == nil || fieldToken.quoted {
+			return nil, fmt.Errorf("expected a field, got %s", fieldToken.describe())
+		}
+		ops, ok := filterable[fieldToken.text]
+		if !ok {
+			return nil, fmt.Errorf("cannot filter by '%s' at position %d, allowed fields are %s",
+				fieldToken.text, fieldToken.pos, strings.Join(sortedMapKeys(filterable), ", "))
+		}
+
+		opToken := parser.next()
+		if opToken == nil || opToken.quoted {
+			return nil, fmt.Errorf("expected an operator, got %s", opToken.describe())
+		}
+		op := strings.ToLower(opToken.text)
+		if !containsString(filterOperators, op) {
+			return nil, fmt.Errorf("unknown operator %s", opToken.describe())
+		}
+		if !containsString(ops, op) {
+			return nil, fmt.Errorf("operator '%s' is not allowed on '%s' at position %d, allowed operators are %s",
+				op, fieldToken.text, opToken.pos, strings.Join(ops, ", "))
+		}
+
+		cond := &FilterCondition{Field: fieldToken.text, Operator: op, Values: make([]string, 0)}
+		if op == OpIn {
+			if token := parser.next(); token == nil || token.quoted || token.text != "(" {
+				return nil, fmt.Errorf("expected '(' after 'in', got %s", token.describe())
+			}
+			for {
+				val, err := parser.value()
+				if err != nil {
+					return nil, err
+				}
+				cond.Values = append(cond.Values, val)
+				token := parser.next()
+				if token != nil && !token.quoted && token.text == ")" {
+					break
+				}
+				if token == nil || token.quoted || token.text != "," {
+					return nil, fmt.Errorf("expected ',' or ')', got %s", token.describe())
+				}
+			}
+		} else {
+			val, err := parser.value()
+			if err != nil {
+				return nil, err
+			}
+			cond.Values = append(cond.Values, val)
+		}
+		ret = append(ret, cond)
+
+		token := parser.next()
+		if token == nil {
+			return ret, nil
+		}
+		if token.quoted || !strings.EqualFold(token.text, "and") {
+			return nil, fmt.Errorf("expected 'and', got %s", token.describe())
+		}
+	}
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedMapKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,181 @@
+package param
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeFilter(t *testing.T) {
+	cases := []struct {
+		name string
+		str  string
+		// tokens 期望的词法单元, 形如 text@pos, 带引号的值前面加上'
+		tokens []string
+		err    string
+	}{
+		{name: "simple", str: "age gt 18", tokens: []string{"age@1", "gt@5", "18@8"}},
+		{name: "quoted value", str: "name eq 'a b'", tokens: []string{"name@1", "eq@6", "'a b@9"}},
+		{name: "escaped quote", str: "name eq 'it''s'", tokens: []string{"name@1", "eq@6", "'it's@9"}},
+		{name: "only escaped quote", str: "''''", tokens: []string{"''@1"}},
+		{name: "empty quoted value", str: "name eq ''", tokens: []string{"name@1", "eq@6", "'@9"}},
+		{name: "in list", str: "status in (1,'a,b')", tokens: []string{"status@1", "in@8", "(@11", "1@12", ",@13", "'a,b@14", ")@19"}},
+		{name: "value next to quote", str: "a eq x'y'", tokens: []string{"a@1", "eq@3", "x@6", "'y@7"}},
+		{name: "multibyte position", str: "名 eq 值", tokens: []string{"名@1", "eq@3", "值@6"}},
+		{name: "unterminated string", str: "name eq 'abc", err: "unterminated string at position 9"},
+		{name: "unterminated after escape", str: "name eq 'a''", err: "unterminated string at position 9"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := tokenizeFilter(c.str)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("got error %v, want %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make([]string, 0, len(tokens))
+			for _, token := range tokens {
+				text := token.text
+				if token.quoted {
+					text = "'" + text
+				}
+				got = append(got, fmt.Sprintf("%s@%d", text, token.pos))
+			}
+			if !reflect.DeepEqual(got, c.tokens) {
+				t.Fatalf("got tokens %v, want %v", got, c.tokens)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filterable := map[string][]string{
+		"age":    {OpGt, OpLt},
+		"name":   {OpEq, OpLike},
+		"status": filterOperators,
+	}
+	cases := []struct {
+		name string
+		str  string
+		want Filter
+		err  string
+	}{
+		{
+			name: "single condition",
+			str:  "age gt 18",
+			want: Filter{{Field: "age", Operator: OpGt, Values: []string{"18"}}},
+		},
+		{
+			name: "conditions joined by and",
+			str:  "age GT 18 AND name like 'it''s'",
+			want: Filter{
+				{Field: "age", Operator: OpGt, Values: []string{"18"}},
+				{Field: "name", Operator: OpLike, Values: []string{"it's"}},
+			},
+		},
+		{
+			name: "in list",
+			str:  "status in (1, 'a b', ')')",
+			want: Filter{{Field: "status", Operator: OpIn, Values: []string{"1", "a b", ")"}}},
+		},
+		{
+			name: "in single value",
+			str:  "status in (1)",
+			want: Filter{{Field: "status", Operator: OpIn, Values: []string{"1"}}},
+		},
+		{name: "unterminated string", str: "name eq 'x", err: "unterminated string at position 9"},
+		{name: "unknown field", str: "age gt 1 and email eq x", err: "cannot filter by 'email' at position 14, allowed fields are age, name, status"},
+		{name: "quoted field", str: "'age' gt 1", err: "expected a field, got 'age' at position 1"},
+		{name: "unknown operator", str: "age between 1", err: "unknown operator 'between' at position 5"},
+		{name: "operator not allowed", str: "age eq 1", err: "operator 'eq' is not allowed on 'age' at position 5, allowed operators are gt, lt"},
+		{name: "missing operator", str: "age", err: "expected an operator, got end of expression"},
+		{name: "missing value", str: "age gt", err: "expected a value, got end of expression"},
+		{name: "parenthesis as value", str: "name eq (", err: "expected a value, got '(' at position 9"},
+		{name: "trailing and", str: "age gt 1 and", err: "expected a field, got end of expression"},
+		{name: "missing and", str: "age gt 1 name eq x", err: "expected 'and', got 'name' at position 10"},
+		{name: "or is not supported", str: "age gt 1 or age lt 5", err: "expected 'and', got 'or' at position 10"},
+		{name: "in without parenthesis", str: "status in 1", err: "expected '(' after 'in', got '1' at position 11"},
+		{name: "unclosed in", str: "status in (1, 2", err: "expected ',' or ')', got end of expression"},
+		{name: "in missing separator", str: "status in (1 2)", err: "expected ',' or ')', got '2' at position 14"},
+		{name: "empty in", str: "status in ()", err: "expected a value, got ')' at position 12"},
+		{name: "trailing comma in", str: "status in (1,)", err: "expected a value, got ')' at position 14"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseFilter(c.str, filterable)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("got error %v, want %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v, want %v", describeFilter(got), describeFilter(c.want))
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	sortable := []string{"createdAt", "name"}
+	cases := []struct {
+		name string
+		str  string
+		want Sort
+		err  string
+	}{
+		{name: "asc and desc", str: "-createdAt, +name", want: Sort{{Field: "createdAt", Desc: true}, {Field: "name"}}},
+		{name: "not sortable", str: "age", err: "cannot sort by 'age', allowed fields are createdAt, name"},
+		{name: "empty field", str: "name,,", err: "empty sort field at index 1"},
+		{name: "duplicate field", str: "name,-name", err: "duplicate sort field 'name'"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseSort(c.str, sortable)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("got error %v, want %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterable(t *testing.T) {
+	got, err := parseFilterable("age:gt|LT, name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"age": {OpGt, OpLt}, "name": filterOperators}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := parseFilterable("age:between"); err == nil || err.Error() != "unknown filter operator 'between' of field 'age'" {
+		t.Fatalf("got error %v", err)
+	}
+}
+
+func describeFilter(filter Filter) []string {
+	ret := make([]string, 0, len(filter))
+	for _, cond := range filter {
+		ret = append(ret, fmt.Sprintf("%s %s %q", cond.Field, cond.Operator, cond.Values))
+	}
+	return ret
+}
@@ -2,12 +2,14 @@ package param
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
 	"github.com/zhyeah/gin-autoreg/util"
 )
 
@@ -17,6 +19,8 @@ const (
 	FROM_FORMDATA = "form"
 	FROM_BODY     = "body"
 	FROM_CONTEXT  = "context"
+	FROM_FILE     = "file"
+	FROM_HEADER   = "header"
 )
 
 // FieldInfo 字段信息
@@ -28,181 +32,579 @@ type FieldInfo struct {
 	DefaultValue string
 	Type         reflect.Type
 	MustHave     bool
+	Tag          reflect.StructTag
+	// Locations 依次查找的来源, 如 from:"header,query", 为空时只使用From和Name
+	Locations []*data.ParamLocation
 }
 
 // ResolvePostDataJson resolve json of controler post data
 func ResolvePostDataJson(ctrl interface{}, methodName string) (string, error) {
+	return ResolvePostDataJsonWithNaming(ctrl, methodName, nil)
+}
+
+// ResolvePostDataJsonWithNaming resolve json of controler post data, names of fields without json tag follow the naming strategy
+func ResolvePostDataJsonWithNaming(ctrl interface{}, methodName string, naming NamingStrategy) (string, error) {
 	// get method by method name
 	method := reflect.ValueOf(ctrl).MethodByName(methodName)
 
 	// find out the specified param
 	methodType := method.Type()
 	for i := 0; i < methodType.NumIn(); i++ {
-		inType := methodType.In(i)
-		if inType.Elem().Kind() == reflect.Struct {
-			paramInstancePtr := reflect.New(inType.Elem())
-			instanceType := paramInstancePtr.Elem().Type()
-			for j := 0; j < instanceType.NumField(); j++ {
-				from := instanceType.Field(j).Tag.Get("from")
-				if from == FROM_BODY {
-					fVal := paramInstancePtr.Elem().Field(j)
-					fType := instanceType.Field(j).Type
-					switch fType.Kind() {
-					case reflect.Slice, reflect.Map, reflect.Struct:
-						bts, err := json.Marshal(fVal.Addr().Interface())
-						return string(bts), err
-					case reflect.Ptr:
-						if fType.Elem().Kind() == reflect.Struct {
-							val := reflect.New(fType.Elem()).Interface()
-							bts, err := json.Marshal(val)
-							return string(bts), err
-						}
-					}
-				}
+		if structType, ok := requestStructType(methodType.In(i)); ok {
+			dataStr, found, err := resolveBodyJson(structType, naming)
+			if found {
+				return dataStr, err
 			}
 		}
 	}
 	return "", nil
 }
 
+// resolveBodyJson 查找结构体(包括匿名嵌入的结构体)中的body字段, 并生成其json示例
+func resolveBodyJson(instanceType reflect.Type, naming NamingStrategy) (string, bool, error) {
+	paramInstancePtr := reflect.New(instanceType)
+	for j := 0; j < instanceType.NumField(); j++ {
+		structField := instanceType.Field(j)
+		from := structField.Tag.Get("from")
+		if from != FROM_BODY || isStreamType(structField.Type) {
+			if structField.Anonymous && isNestedParamStruct(&structField, from) {
+				embeddedType := structField.Type
+				if embeddedType.Kind() == reflect.Ptr {
+					embeddedType = embeddedType.Elem()
+				}
+				if dataStr, found, err := resolveBodyJson(embeddedType, naming); found {
+					return dataStr, found, err
+				}
+			}
+			continue
+		}
+
+		fVal := paramInstancePtr.Elem().Field(j)
+		fType := structField.Type
+		switch fType.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Struct:
+			bts, err := json.Marshal(fVal.Addr().Interface())
+			if err != nil {
+				return "", true, err
+			}
+			dataStr, err := util.RenameJSONFields(string(bts), fType, naming.Name)
+			return dataStr, true, err
+		case reflect.Ptr:
+			if fType.Elem().Kind() == reflect.Struct {
+				val := reflect.New(fType.Elem()).Interface()
+				bts, err := json.Marshal(val)
+				if err != nil {
+					return "", true, err
+				}
+				dataStr, err := util.RenameJSONFields(string(bts), fType, naming.Name)
+				return dataStr, true, err
+			}
+		}
+	}
+	return "", false, nil
+}
+
 // ResolveParams 解析controller action需要的参数
 func ResolveParams(ctrl interface{}, methodName string, ctx *gin.Context) ([]interface{}, error) {
 	ret := make([]interface{}, 0)
+	errs := make(ValidationErrors, 0)
+
+	// 所有读取请求体的绑定方式共用路由的最大长度限制
+	limitBody(ctx)
 
 	// 获取ctrl的methodName的方法
 	method := reflect.ValueOf(ctrl).MethodByName(methodName)
 
-	// 遍历method的参数，如果是*struct类型，对字段进行解析，如果是*gin.Context类型，直接塞入，其他类型则抛异常
+	// 遍历method的参数，*gin.Context和context.Context直接塞入，注册了提供者的类型调用提供者获得，
+	// struct或*struct类型对字段进行解析，其他类型则抛异常
 	methodType := method.Type()
 	for i := 0; i < methodType.NumIn(); i++ {
 		inType := methodType.In(i)
-		if inType.Kind().String() != reflect.Ptr.String() {
-			return nil, errors.New("the parameters of method should be ptr")
-		}
-		if inType.Elem().PkgPath() == "github.com/gin-gonic/gin" && inType.Elem().Name() == "Context" {
+		if inType == ginContextType {
 			ret = append(ret, ctx)
-		} else if inType.Elem().Kind().String() == reflect.Struct.String() {
+		} else if inType == contextType {
+			ret = append(ret, ctx.Request.Context())
+		} else if provider, ok := getProvider(inType); ok {
+			val, err := resolveProvided(provider, ctx)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, val)
+		} else if structType, ok := requestStructType(inType); ok {
 			// 首先new一个instance
-			paramInstancePtr := reflect.New(inType.Elem())
-			instanceType := paramInstancePtr.Elem().Type()
-			for j := 0; j < instanceType.NumField(); j++ {
-				err := SetFieldValue(&FieldInfo{
-					Field:        paramInstancePtr.Elem().Field(j),
-					FieldName:    instanceType.Field(j).Name,
-					Name:         instanceType.Field(j).Tag.Get("field"),
-					From:         instanceType.Field(j).Tag.Get("from"),
-					DefaultValue: instanceType.Field(j).Tag.Get("default"),
-					MustHave:     util.ConvertStringToBoolDefault(instanceType.Field(j).Tag.Get("must"), true),
-					Type:         instanceType.Field(j).Type,
-				}, ctx)
-
-				if err != nil {
-					return nil, err
-				}
+			paramInstancePtr := reflect.New(structType)
+			err := resolveStructFields(paramInstancePtr.Elem(), ctx, &errs)
+			if err != nil {
+				return nil, err
+			}
+			err = resolveHooks(paramInstancePtr, NamingOf(ctx), &errs)
+			if err != nil {
+				return nil, err
+			}
+			if inType.Kind() == reflect.Ptr {
+				ret = append(ret, paramInstancePtr.Interface())
+			} else {
+				ret = append(ret, paramInstancePtr.Elem().Interface())
 			}
-			ret = append(ret, paramInstancePtr.Interface())
 		} else {
-			return nil, fmt.Errorf("unsupport controller param ptr type %s", inType.Elem().Name())
+			return nil, fmt.Errorf("unsupport controller param type %s", inType)
 		}
 	}
 
+	if len(errs) > 0 {
+		return nil, errs.ToHTTPException()
+	}
 	return ret, nil
 }
 
+// CheckParams 在注册路由时检查controller action参数的定义是否合法
+func CheckParams(ctrl interface{}, methodName string, naming NamingStrategy) error {
+	methodType := reflect.ValueOf(ctrl).MethodByName(methodName).Type()
+	for i := 0; i < methodType.NumIn(); i++ {
+		if err := checkParamType(methodType.In(i)); err != nil {
+			return err
+		}
+		structType, ok := requestStructType(methodType.In(i))
+		if !ok {
+			continue
+		}
+		err := walkParamFields(structType, naming, func(structField *reflect.StructField, from string, name string) error {
+			if _, err := paramLocations(from, name); err != nil {
+				return fmt.Errorf("field '%s': %s", structField.Name, err.Error())
+			}
+			if isURLValuesMap(structField.Type, from) {
+				if err := checkURLValuesMap(structField.Type); err != nil {
+					return fmt.Errorf("field '%s': %s", structField.Name, err.Error())
+				}
+			}
+			if err := checkPageField(structField, from); err != nil {
+				return fmt.Errorf("field '%s': %s", structField.Name, err.Error())
+			}
+			if err := checkQueryDSLTag(structField); err != nil {
+				return fmt.Errorf("field '%s': %s", structField.Name, err.Error())
+			}
+			name = firstParamName(name)
+			if _, err := parseValidateTag(structField.Tag.Get("validate")); err != nil {
+				return fmt.Errorf("field '%s': %s", name, err.Error())
+			}
+			if from == FROM_BODY {
+				return checkValidateTags(structField.Type, make(map[reflect.Type]bool))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveStructFields 解析结构体的各个字段, 字段绑定的错误会收集到errs中, 只有无法继续处理的错误才会直接返回
+func resolveStructFields(structVal reflect.Value, ctx *gin.Context, errs *ValidationErrors) error {
+	return walkParamValues(structVal, NamingOf(ctx), func(structField *reflect.StructField, fieldVal reflect.Value, from string, name string) error {
+		locations, err := paramLocations(from, name)
+		if err != nil {
+			return err
+		}
+		if len(locations) > 0 {
+			from, name = locations[0].From, locations[0].Name
+		}
+		err = SetFieldValue(&FieldInfo{
+			Field:        fieldVal,
+			FieldName:    structField.Name,
+			Name:         name,
+			From:         from,
+			DefaultValue: structField.Tag.Get("default"),
+			MustHave:     util.ConvertStringToBoolDefault(structField.Tag.Get("must"), true),
+			Type:         structField.Type,
+			Tag:          structField.Tag,
+			Locations:    locations,
+		}, ctx)
+		if err != nil {
+			return collectError(err, name, errs)
+		}
+		return nil
+	})
+}
+
+// validateStructFields 使用validate标签校验已绑定的结构体, 绑定失败的字段不再校验
+func validateStructFields(structVal reflect.Value, naming NamingStrategy, errs *ValidationErrors) {
+	failed := make(map[string]bool)
+	for _, err := range *errs {
+		failed[err.Field] = true
+	}
+	walkParamValues(structVal, naming, func(structField *reflect.StructField, fieldVal reflect.Value, from string, name string) error {
+		name = firstParamName(name)
+		if from == "" || failed[name] {
+			return nil
+		}
+		// 可选字段没有值时不做校验
+		if util.ConvertStringToBoolDefault(structField.Tag.Get("must"), true) || !fieldVal.IsZero() {
+			validateField(fieldVal, name, structField.Tag.Get("validate"), errs)
+		}
+		if from == FROM_BODY {
+			validateValue(fieldVal, structField.Tag.Get("path"), naming, errs)
+		}
+		return nil
+	})
+}
+
+// collectError 收集字段的绑定错误, 无法收集的错误(如HTTPException)会被返回
+func collectError(err error, name string, errs *ValidationErrors) error {
+	switch e := err.(type) {
+	case *exception.HTTPException:
+		return e
+	case *ValidationError:
+		*errs = append(*errs, e)
+	case ValidationErrors:
+		*errs = append(*errs, e...)
+	default:
+		*errs = append(*errs, &ValidationError{Field: name, Rule: RuleInvalid, Message: err.Error()})
+	}
+	return nil
+}
+
+// walkParamValues 遍历参数结构体的字段, 匿名嵌入的结构体字段视为提升字段,
+// 非body来源的嵌套结构体会递归遍历(为nil的指针会被初始化), 其字段名可通过'prefix'标签加上前缀.
+// 嵌套的结构体引用了自身时返回错误
+func walkParamValues(structVal reflect.Value, naming NamingStrategy, fn func(structField *reflect.StructField, fieldVal reflect.Value, from string, name string) error) error {
+	return walkNestedParamValues(structVal, "", "", naming, map[reflect.Type]bool{structVal.Type(): true}, fn)
+}
+
+// walkNestedParamValues 递归遍历参数结构体, visiting为当前路径上的结构体类型
+func walkNestedParamValues(structVal reflect.Value, from string, prefix string, naming NamingStrategy, visiting map[reflect.Type]bool,
+	fn func(structField *reflect.StructField, fieldVal reflect.Value, from string, name string) error) error {
+	structType := structVal.Type()
+	for j := 0; j < structType.NumField(); j++ {
+		structField := structType.Field(j)
+		if structField.PkgPath != "" && !structField.Anonymous {
+			continue
+		}
+
+		fieldFrom := structField.Tag.Get("from")
+		if fieldFrom == "" {
+			fieldFrom = from
+		}
+
+		fieldVal := structVal.Field(j)
+		if isNestedParamStruct(&structField, fieldFrom) {
+			nestedType := indirectType(structField.Type)
+			if visiting[nestedType] {
+				return fmt.Errorf("field '%s': param struct %s refers to itself", structField.Name, nestedType)
+			}
+			if fieldVal.Kind() == reflect.Ptr {
+				if fieldVal.IsNil() {
+					fieldVal.Set(reflect.New(structField.Type.Elem()))
+				}
+				fieldVal = fieldVal.Elem()
+			}
+			visiting[nestedType] = true
+			err := walkNestedParamValues(fieldVal, fieldFrom, joinParamKey(prefix, structField.Tag.Get("prefix")), naming, visiting, fn)
+			delete(visiting, nestedType)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := structField.Tag.Get("field")
+		if name == "" {
+			name = defaultParamName(structField.Name, fieldFrom, naming)
+		}
+		if err := fn(&structField, fieldVal, fieldFrom, joinParamKeys(prefix, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultParamName 字段没有'field'标签时的参数名, context中的key由代码设置, 不受命名策略影响
+func defaultParamName(fieldName string, from string, naming NamingStrategy) string {
+	if from == FROM_CONTEXT {
+		return util.FirstToLower(fieldName)
+	}
+	return naming.Name(fieldName)
+}
+
+// walkParamFields 按照walkParamValues相同的规则遍历参数结构体类型的字段
+func walkParamFields(structType reflect.Type, naming NamingStrategy, fn func(structField *reflect.StructField, from string, name string) error) error {
+	return walkParamValues(reflect.New(structType).Elem(), naming, func(structField *reflect.StructField, fieldVal reflect.Value, from string, name string) error {
+		return fn(structField, from, name)
+	})
+}
+
+// isNestedParamStruct 判断字段是否为需要展开绑定的结构体
+func isNestedParamStruct(structField *reflect.StructField, from string) bool {
+	fieldType := structField.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct || fieldType == pageType || fieldType == timeType || from == FROM_BODY || from == FROM_FILE || from == FROM_CONTEXT {
+		return false
+	}
+	return structField.Anonymous || from != ""
+}
+
+// joinParamKey 使用'.'拼接参数名前缀
+func joinParamKey(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+// joinParamKeys 为逗号分隔的多个参数名分别加上前缀
+func joinParamKeys(prefix string, names string) string {
+	if prefix == "" {
+		return names
+	}
+	keys := strings.Split(names, ",")
+	for i := range keys {
+		keys[i] = joinParamKey(prefix, keys[i])
+	}
+	return strings.Join(keys, ",")
+}
+
+// joinLocationSources 字段的全部来源, 以逗号分隔
+func joinLocationSources(fieldInfo *FieldInfo) string {
+	if len(fieldInfo.Locations) == 0 {
+		return fieldInfo.From
+	}
+	froms := make([]string, 0, len(fieldInfo.Locations))
+	for _, location := range fieldInfo.Locations {
+		froms = append(froms, location.From)
+	}
+	return strings.Join(froms, ",")
+}
+
+// firstParamName 多来源的字段使用第一个参数名报告错误
+func firstParamName(names string) string {
+	return strings.Split(names, ",")[0]
+}
+
+// paramLocations 解析逗号分隔的来源和参数名, 参数名只有一个时所有来源共用, 否则需要与来源一一对应.
+// body, file和context不能与其他来源组合
+func paramLocations(from string, names string) ([]*data.ParamLocation, error) {
+	if from == "" {
+		return nil, nil
+	}
+	froms := strings.Split(from, ",")
+	keys := strings.Split(names, ",")
+	if len(keys) != 1 && len(keys) != len(froms) {
+		return nil, fmt.Errorf("%d names given for %d sources '%s'", len(keys), len(froms), from)
+	}
+	locations := make([]*data.ParamLocation, 0, len(froms))
+	for i, source := range froms {
+		source = strings.TrimSpace(source)
+		isSpecial := source == FROM_BODY || source == FROM_FILE || source == FROM_CONTEXT
+		if len(froms) > 1 && (isSpecial || source == "") {
+			return nil, fmt.Errorf("source '%s' cannot be combined with others", source)
+		}
+		// 自定义来源需要在注册路由之前注册
+		if _, ok := getSource(source); !ok && !isSpecial {
+			return nil, fmt.Errorf("unknown source '%s'", source)
+		}
+		key := keys[0]
+		if len(keys) > 1 {
+			key = keys[i]
+		}
+		locations = append(locations, &data.ParamLocation{From: source, Name: strings.TrimSpace(key)})
+	}
+	return locations, nil
+}
+
 // SetFieldValue 根据字段信息, 设置gin.Context中的值
 func SetFieldValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
 	if fieldInfo.From == "" {
 		return nil
 	}
+	if fieldInfo.From == FROM_FILE {
+		return setUploadValue(fieldInfo, ctx)
+	}
+	if fieldInfo.From == FROM_CONTEXT {
+		if fieldInfo.Name == "" {
+			fieldInfo.Name = defaultParamName(fieldInfo.FieldName, fieldInfo.From, NamingOf(ctx))
+		}
+		return setContextValue(fieldInfo, ctx)
+	}
+	if fieldInfo.From == FROM_BODY && isStreamType(fieldInfo.Type) {
+		return setStreamValue(fieldInfo, ctx)
+	}
+	if isURLValuesMap(fieldInfo.Type, joinLocationSources(fieldInfo)) {
+		return setMapValue(fieldInfo, ctx)
+	}
+	if isPageType(fieldInfo.Type) {
+		return setPageValue(fieldInfo, ctx)
+	}
+	if indirectType(fieldInfo.Type) == timeType && fieldInfo.From != FROM_BODY {
+		return setTimeValue(fieldInfo, ctx)
+	}
+	if isQueryDSLType(fieldInfo.Type) {
+		valStr, err := getValueFromContext(fieldInfo, ctx)
+		if err != nil {
+			return err
+		}
+		return setQueryDSLValue(fieldInfo, valStr)
+	}
 
 	// 根据字段类型设置value
 	switch fieldInfo.Type.Kind().String() {
 	case reflect.Int.String(), reflect.Int8.String(), reflect.Int16.String(), reflect.Int32.String(), reflect.Int64.String():
-		valStr := getValueFromContext(fieldInfo, ctx)
+		valStr, err := getValueFromContext(fieldInfo, ctx)
+		if err != nil {
+			return err
+		}
 		intVal, err := util.ConvertStringToInt64(valStr)
 		if err != nil && fieldInfo.MustHave {
-			return fmt.Errorf("field '%s' val '%s' cannot convert to int", fieldInfo.Name, valStr)
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleType,
+				Message: fmt.Sprintf("field '%s' val '%s' cannot convert to int", fieldInfo.Name, valStr),
+			}
 		}
 		fieldInfo.Field.SetInt(intVal)
 	case reflect.Uint.String(), reflect.Uint8.String(), reflect.Uint16.String(), reflect.Uint32.String(), reflect.Uint64.String():
-		valStr := getValueFromContext(fieldInfo, ctx)
+		valStr, err := getValueFromContext(fieldInfo, ctx)
+		if err != nil {
+			return err
+		}
 		intVal, err := util.ConvertStringToUInt64(valStr)
 		if err != nil && fieldInfo.MustHave {
-			return fmt.Errorf("field '%s' val '%s' cannot convert to unsigned int", fieldInfo.Name, valStr)
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleType,
+				Message: fmt.Sprintf("field '%s' val '%s' cannot convert to unsigned int", fieldInfo.Name, valStr),
+			}
 		}
 		fieldInfo.Field.SetUint(intVal)
 	case reflect.Bool.String():
-		valStr := getValueFromContext(fieldInfo, ctx)
+		valStr, err := getValueFromContext(fieldInfo, ctx)
+		if err != nil {
+			return err
+		}
 		boolVal, err := util.ConvertStringToBool(valStr)
 		if err != nil && fieldInfo.MustHave {
-			return fmt.Errorf("field '%s' val '%s' cannot convert to bool", fieldInfo.Name, valStr)
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleType,
+				Message: fmt.Sprintf("field '%s' val '%s' cannot convert to bool", fieldInfo.Name, valStr),
+			}
 		}
 		fieldInfo.Field.SetBool(boolVal)
 	case reflect.String.String():
-		valStr := getValueFromContext(fieldInfo, ctx)
+		valStr, err := getValueFromContext(fieldInfo, ctx)
+		if err != nil {
+			return err
+		}
 		if valStr == "" && fieldInfo.MustHave {
-			return fmt.Errorf("field '%s' must have val, but now it's empty", fieldInfo.Name)
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but now it's empty", fieldInfo.Name),
+			}
 		}
 		fieldInfo.Field.SetString(valStr)
+	case reflect.Float32.String(), reflect.Float64.String():
+		return setScalarValue(fieldInfo, ctx)
 	case reflect.Slice.String(), reflect.Map.String(), reflect.Struct.String():
-		val := fieldInfo.Field.Addr().Interface()
-		defer ctx.Request.Body.Close()
-		body, _ := ioutil.ReadAll(ctx.Request.Body)
-		err := util.AdaptJSONForDTO(string(body), val)
-		if err != nil {
-			return err
-		}
-		fieldInfo.Field.Set(reflect.ValueOf(val).Elem())
+		return setBodyValue(fieldInfo, ctx)
 	case reflect.Ptr.String():
-		if fieldInfo.Type.Elem().Kind().String() == reflect.Struct.String() {
-			val := reflect.New(fieldInfo.Type.Elem()).Interface()
-			// 这里手动强制适配
-			defer ctx.Request.Body.Close()
-			body, _ := ioutil.ReadAll(ctx.Request.Body)
-			err := util.AdaptJSONForDTO(string(body), val)
-			if err != nil {
-				return err
-			}
-			fieldInfo.Field.Set(reflect.ValueOf(val))
-		} else if fieldInfo.Type.Elem().Kind().String() == reflect.Map.String() {
-			keyType := fieldInfo.Type.Elem().Key()
-			valType := fieldInfo.Type.Elem().Elem()
-			val := reflect.New(reflect.MapOf(keyType, valType)).Interface()
-			defer ctx.Request.Body.Close()
-			body, _ := ioutil.ReadAll(ctx.Request.Body)
-			err := util.AdaptJSONForDTO(string(body), val)
-			if err != nil {
-				return err
+		switch kind := fieldInfo.Type.Elem().Kind(); {
+		case kind == reflect.Struct, kind == reflect.Map, kind == reflect.Slice:
+			return setBodyValue(fieldInfo, ctx)
+		case isScalarKind(kind):
+			return setScalarValue(fieldInfo, ctx)
+		}
+	}
+	return nil
+}
+
+// setTimeValue 按照'layout'标签解析非body来源的时间字段, 默认为RFC3339
+func setTimeValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	valStr, err := getValueFromContext(fieldInfo, ctx)
+	if err != nil {
+		return err
+	}
+	if valStr == "" {
+		if fieldInfo.MustHave {
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but now it's empty", fieldInfo.Name),
 			}
-			fieldInfo.Field.Set(reflect.ValueOf(val))
-		} else if fieldInfo.Type.Elem().Kind().String() == reflect.Slice.String() {
-			listType := fieldInfo.Type.Elem().Elem()
-			val := reflect.New(reflect.SliceOf(listType)).Interface()
-			defer ctx.Request.Body.Close()
-			body, _ := ioutil.ReadAll(ctx.Request.Body)
-			err := util.AdaptJSONForDTO(string(body), val)
-			if err != nil {
-				return err
+		}
+		return nil
+	}
+	layout := fieldInfo.Tag.Get("layout")
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	timeVal, err := time.Parse(layout, valStr)
+	if err != nil {
+		return &ValidationError{
+			Field:   fieldInfo.Name,
+			Rule:    RuleType,
+			Message: fmt.Sprintf("field '%s' val '%s' cannot convert to time of layout '%s'", fieldInfo.Name, valStr, layout),
+		}
+	}
+	if fieldInfo.Type.Kind() == reflect.Ptr {
+		fieldInfo.Field.Set(reflect.ValueOf(&timeVal))
+	} else {
+		fieldInfo.Field.Set(reflect.ValueOf(timeVal))
+	}
+	return nil
+}
+
+// setScalarValue 使用setStringValue转换浮点数和指向基本类型的指针, 值为空时指针保持为nil
+func setScalarValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	valStr, err := getValueFromContext(fieldInfo, ctx)
+	if err != nil {
+		return err
+	}
+	if valStr == "" {
+		if fieldInfo.MustHave {
+			return &ValidationError{
+				Field:   fieldInfo.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have val, but now it's empty", fieldInfo.Name),
 			}
-			fieldInfo.Field.Set(reflect.ValueOf(val))
+		}
+		return nil
+	}
+	if err := setStringValue(fieldInfo.Field, valStr); err != nil {
+		return &ValidationError{
+			Field:   fieldInfo.Name,
+			Rule:    RuleType,
+			Message: fmt.Sprintf("field '%s' val '%s' cannot convert to %s", fieldInfo.Name, valStr, indirectType(fieldInfo.Type)),
 		}
 	}
 	return nil
 }
 
-func getValueFromContext(fieldInfo *FieldInfo, ctx *gin.Context) string {
+// getValueFromContext 从字段的来源中取值, 没有取到时使用默认值
+func getValueFromContext(fieldInfo *FieldInfo, ctx *gin.Context) (string, error) {
 	if fieldInfo.Name == "" {
-		fieldInfo.Name = util.FirstToLower(fieldInfo.FieldName)
-	}
-	switch fieldInfo.From {
-	case FROM_QUERY:
-		return ctx.DefaultQuery(fieldInfo.Name, fieldInfo.DefaultValue)
-	case FROM_PATH:
-		return ctx.Param(fieldInfo.Name)
-	case FROM_FORMDATA:
-		return ctx.DefaultPostForm(fieldInfo.Name, fieldInfo.DefaultValue)
-	case FROM_CONTEXT:
-		return ctx.GetString(fieldInfo.Name)
-	}
-	return fieldInfo.DefaultValue
+		fieldInfo.Name = defaultParamName(fieldInfo.FieldName, fieldInfo.From, NamingOf(ctx))
+	}
+	locations := fieldInfo.Locations
+	if len(locations) == 0 {
+		locations = []*data.ParamLocation{{From: fieldInfo.From, Name: fieldInfo.Name}}
+	}
+	// 依次查找各个来源, 使用第一个找到的值
+	for _, location := range locations {
+		locationInfo := *fieldInfo
+		locationInfo.From, locationInfo.Name = location.From, location.Name
+		source, ok := getSource(location.From)
+		if !ok {
+			continue
+		}
+		val, found, err := source.Lookup(ctx, &locationInfo)
+		if err != nil || found {
+			return val, err
+		}
+	}
+	return fieldInfo.DefaultValue, nil
 }
@@ -0,0 +1,126 @@
+package param
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/util"
+)
+
+// Source 参数来源, 注册后可以通过 from:"<Name()>" 使用, 取到的字符串与内置来源一样进行类型转换, 默认值, must和validate校验
+type Source interface {
+	// Name 来源的名称, 即from标签的值
+	Name() string
+	// Lookup 查找字段的值, 没有找到时返回false, 返回的error会作为该字段的绑定错误, *exception.HTTPException则直接响应
+	Lookup(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error)
+}
+
+// SourceFunc 函数形式的参数来源
+type SourceFunc struct {
+	SourceName string
+	LookupFunc func(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error)
+}
+
+// Name 来源的名称
+func (source *SourceFunc) Name() string {
+	return source.SourceName
+}
+
+// Lookup 查找字段的值
+func (source *SourceFunc) Lookup(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error) {
+	return source.LookupFunc(ctx, fieldInfo)
+}
+
+var sourcesLock sync.RWMutex
+var sources = map[string]Source{}
+
+func init() {
+	registerSource(&SourceFunc{SourceName: FROM_QUERY, LookupFunc: func(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error) {
+		val, ok := ctx.GetQuery(fieldInfo.Name)
+		return val, ok, nil
+	}})
+	registerSource(&SourceFunc{SourceName: FROM_PATH, LookupFunc: func(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error) {
+		val, ok := ctx.Params.Get(fieldInfo.Name)
+		return val, ok, nil
+	}})
+	registerSource(&SourceFunc{SourceName: FROM_FORMDATA, LookupFunc: func(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error) {
+		// 解析表单的错误(如请求体超过最大长度)需要返回, 不能当作参数不存在
+		values, err := urlValuesOf(FROM_FORMDATA, ctx)
+		if err != nil {
+			return "", false, err
+		}
+		if vals := values[fieldInfo.Name]; len(vals) > 0 {
+			return vals[0], true, nil
+		}
+		return "", false, nil
+	}})
+	registerSource(&SourceFunc{SourceName: FROM_HEADER, LookupFunc: func(ctx *gin.Context, fieldInfo *FieldInfo) (string, bool, error) {
+		val := ctx.GetHeader(fieldInfo.Name)
+		return val, val != "", nil
+	}})
+}
+
+// RegisterSource 注册参数来源, 名称不能与已有的来源重复
+func RegisterSource(source Source) error {
+	name := source.Name()
+	switch name {
+	case "", FROM_BODY, FROM_CONTEXT, FROM_FILE:
+		return fmt.Errorf("invalid param source name '%s'", name)
+	}
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	if _, ok := sources[name]; ok {
+		return fmt.Errorf("param source '%s' is already registered", name)
+	}
+	sources[name] = source
+	return nil
+}
+
+func registerSource(source Source) {
+	sources[source.Name()] = source
+}
+
+// getSource 获取名称对应的参数来源
+func getSource(name string) (Source, bool) {
+	sourcesLock.RLock()
+	defer sourcesLock.RUnlock()
+	source, ok := sources[name]
+	return source, ok
+}
+
+// ResolveParamInfos 解析controller action的参数字段信息, 用于路由元数据
+func ResolveParamInfos(ctrl interface{}, methodName string, naming NamingStrategy) ([]*data.ParamInfo, error) {
+	ret := make([]*data.ParamInfo, 0)
+	methodType := reflect.ValueOf(ctrl).MethodByName(methodName).Type()
+	for i := 0; i < methodType.NumIn(); i++ {
+		structType, ok := requestStructType(methodType.In(i))
+		if !ok {
+			continue
+		}
+		err := walkParamFields(structType, naming, func(structField *reflect.StructField, from string, name string) error {
+			locations, err := paramLocations(from, name)
+			if err != nil || len(locations) == 0 {
+				return err
+			}
+			info := &data.ParamInfo{
+				Field:     structField.Name,
+				Name:      locations[0].Name,
+				From:      locations[0].From,
+				Locations: locations,
+				Type:      structField.Type.String(),
+				Required:  util.ConvertStringToBoolDefault(structField.Tag.Get("must"), true),
+				Default:   structField.Tag.Get("default"),
+			}
+			queryDSLInfo(structField, info)
+			ret = append(ret, info)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
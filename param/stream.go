@@ -0,0 +1,158 @@
+package param
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ioReaderType   = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	streamType     = reflect.TypeOf(Stream{})
+	jsonStreamType = reflect.TypeOf(JSONStream{})
+)
+
+// Stream 未读取的请求体, 读取时会检查请求是否已取消以及是否超过路由配置的最大长度
+type Stream struct {
+	ContentType string
+	reader      io.Reader
+}
+
+// Read 读取请求体
+func (stream *Stream) Read(p []byte) (int, error) {
+	if stream.reader == nil {
+		return 0, io.EOF
+	}
+	return stream.reader.Read(p)
+}
+
+// JSONStream 逐个解码请求体中的json元素, 请求体可以是json数组, 也可以是以换行分隔的json(NDJSON)
+type JSONStream struct {
+	Stream
+	decoder *json.Decoder
+	inArray bool
+	started bool
+}
+
+// Next 解码下一个元素到v中, 没有更多元素时返回io.EOF
+func (stream *JSONStream) Next(v interface{}) error {
+	if !stream.started {
+		stream.started = true
+		if err := stream.start(); err != nil {
+			return err
+		}
+	}
+
+	if !stream.decoder.More() {
+		if stream.inArray {
+			if _, err := stream.decoder.Token(); err != nil {
+				return err
+			}
+			stream.inArray = false
+		}
+		return io.EOF
+	}
+	return stream.decoder.Decode(v)
+}
+
+// start 判断请求体是否为json数组, 是则跳过数组的起始符号
+func (stream *JSONStream) start() error {
+	reader := bufio.NewReader(&stream.Stream)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			stream.decoder = json.NewDecoder(reader)
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		reader.UnreadByte()
+		stream.decoder = json.NewDecoder(reader)
+		if b == '[' {
+			stream.inArray = true
+			_, err := stream.decoder.Token()
+			return err
+		}
+		return nil
+	}
+}
+
+// isStreamType 判断字段是否需要以流的形式绑定请求体
+func isStreamType(typ reflect.Type) bool {
+	if typ == ioReaderType {
+		return true
+	}
+	typ = indirectType(typ)
+	return typ == streamType || typ == jsonStreamType
+}
+
+// setStreamValue 将未读取的请求体绑定到流类型的字段上
+func setStreamValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	if maxSize := MaxBodySize(ctx); maxSize > 0 && ctx.Request.ContentLength > maxSize {
+		return bodyTooLarge(maxSize)
+	}
+	stream := Stream{
+		ContentType: ctx.GetHeader("Content-Type"),
+		reader:      newStreamReader(ctx),
+	}
+
+	var val reflect.Value
+	switch indirectType(fieldInfo.Type) {
+	case jsonStreamType:
+		val = reflect.ValueOf(&JSONStream{Stream: stream})
+	default:
+		val = reflect.ValueOf(&stream)
+	}
+	if fieldInfo.Type.Kind() != reflect.Ptr && fieldInfo.Type.Kind() != reflect.Interface {
+		val = val.Elem()
+	}
+	fieldInfo.Field.Set(val)
+	return nil
+}
+
+// streamReader 检查请求是否取消以及长度限制的reader
+type streamReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	maxSize int64
+	read    int64
+}
+
+func newStreamReader(ctx *gin.Context) io.Reader {
+	if ctx.Request.Body == nil {
+		return nil
+	}
+	return &streamReader{
+		ctx:     ctx.Request.Context(),
+		reader:  ctx.Request.Body,
+		maxSize: MaxBodySize(ctx),
+	}
+}
+
+// Read 读取请求体, 请求被取消时返回ctx的错误, 超过最大长度时返回413异常
+func (reader *streamReader) Read(p []byte) (int, error) {
+	if err := reader.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if reader.maxSize > 0 && reader.read > reader.maxSize {
+		return 0, bodyTooLarge(reader.maxSize)
+	}
+	if reader.maxSize > 0 && int64(len(p)) > reader.maxSize-reader.read+1 {
+		p = p[:reader.maxSize-reader.read+1]
+	}
+	n, err := reader.reader.Read(p)
+	reader.read += int64(n)
+	if reader.maxSize > 0 && reader.read > reader.maxSize {
+		return n, bodyTooLarge(reader.maxSize)
+	}
+	return n, err
+}
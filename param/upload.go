@@ -0,0 +1,199 @@
+package param
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/util"
+)
+
+const closersKey = "autoroute.param.closers"
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	multipartFileType   = reflect.TypeOf((*multipart.File)(nil)).Elem()
+	readCloserType      = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+)
+
+// ResolveUploads 解析controller action中上传文件字段的信息
+func ResolveUploads(ctrl interface{}, methodName string, naming NamingStrategy) ([]*data.UploadInfo, error) {
+	ret := make([]*data.UploadInfo, 0)
+	methodType := reflect.ValueOf(ctrl).MethodByName(methodName).Type()
+	for i := 0; i < methodType.NumIn(); i++ {
+		structType, ok := requestStructType(methodType.In(i))
+		if !ok {
+			continue
+		}
+		err := walkParamFields(structType, naming, func(structField *reflect.StructField, from string, name string) error {
+			if from != FROM_FILE {
+				return nil
+			}
+			info, err := parseUploadInfo(name, structField.Type, structField.Tag,
+				util.ConvertStringToBoolDefault(structField.Tag.Get("must"), true))
+			if err != nil {
+				return err
+			}
+			ret = append(ret, info)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// ReleaseParams 释放绑定参数时打开的资源, 如上传的文件
+func ReleaseParams(ctx *gin.Context) {
+	val, ok := ctx.Get(closersKey)
+	if !ok {
+		return
+	}
+	for _, closer := range val.([]io.Closer) {
+		closer.Close()
+	}
+	ctx.Set(closersKey, []io.Closer{})
+}
+
+// addCloser 记录需要在请求结束时关闭的资源
+func addCloser(ctx *gin.Context, closer io.Closer) {
+	closers := make([]io.Closer, 0)
+	if val, ok := ctx.Get(closersKey); ok {
+		closers = val.([]io.Closer)
+	}
+	ctx.Set(closersKey, append(closers, closer))
+}
+
+// parseUploadInfo 根据字段类型和标签生成上传文件信息
+func parseUploadInfo(name string, typ reflect.Type, tag reflect.StructTag, must bool) (*data.UploadInfo, error) {
+	info := &data.UploadInfo{
+		Name:         name,
+		Required:     must,
+		ContentTypes: make([]string, 0),
+	}
+
+	switch typ {
+	case fileHeaderSliceType:
+		info.Multiple = true
+	case fileHeaderType, multipartFileType, readCloserType:
+	default:
+		return nil, fmt.Errorf("field '%s' of type %s cannot bind file", name, typ)
+	}
+
+	if maxSize := tag.Get("maxSize"); maxSize != "" {
+		size, err := util.ParseByteSize(maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s' has invalid maxSize '%s'", name, maxSize)
+		}
+		info.MaxSize = size
+	}
+	if maxCount := tag.Get("maxCount"); maxCount != "" {
+		count, err := util.ConvertStringToInt(maxCount)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s' has invalid maxCount '%s'", name, maxCount)
+		}
+		info.MaxCount = count
+	}
+	if accept := tag.Get("accept"); accept != "" {
+		for _, contentType := range strings.Split(accept, ",") {
+			info.ContentTypes = append(info.ContentTypes, strings.ToLower(strings.TrimSpace(contentType)))
+		}
+	}
+	return info, nil
+}
+
+// setUploadValue 将multipart表单中的文件绑定到字段上
+func setUploadValue(fieldInfo *FieldInfo, ctx *gin.Context) error {
+	if fieldInfo.Name == "" {
+		fieldInfo.Name = NamingOf(ctx).Name(fieldInfo.FieldName)
+	}
+	info, err := parseUploadInfo(fieldInfo.Name, fieldInfo.Type, fieldInfo.Tag, fieldInfo.MustHave)
+	if err != nil {
+		return err
+	}
+
+	var files []*multipart.FileHeader
+	limitBody(ctx)
+	form, err := ctx.MultipartForm()
+	if limitErr := bodyLimitError(ctx, err); limitErr != err {
+		return limitErr
+	}
+	if err == nil && form != nil {
+		files = form.File[info.Name]
+	}
+	if len(files) == 0 {
+		if info.Required {
+			return &ValidationError{
+				Field:   info.Name,
+				Rule:    RuleRequired,
+				Message: fmt.Sprintf("field '%s' must have file, but now it's empty", info.Name),
+			}
+		}
+		return nil
+	}
+	if !info.Multiple {
+		files = files[:1]
+	} else if info.MaxCount > 0 && len(files) > info.MaxCount {
+		return &ValidationError{
+			Field:   info.Name,
+			Rule:    "maxCount",
+			Message: fmt.Sprintf("field '%s' accepts at most %d files, but got %d", info.Name, info.MaxCount, len(files)),
+		}
+	}
+
+	for _, file := range files {
+		if info.MaxSize > 0 && file.Size > info.MaxSize {
+			return &ValidationError{
+				Field:   info.Name,
+				Rule:    "maxSize",
+				Message: fmt.Sprintf("file '%s' of field '%s' exceeds max size %d bytes", file.Filename, info.Name, info.MaxSize),
+			}
+		}
+		if len(info.ContentTypes) > 0 && !matchContentType(file.Header.Get("Content-Type"), info.ContentTypes) {
+			return &ValidationError{
+				Field:   info.Name,
+				Rule:    "accept",
+				Message: fmt.Sprintf("file '%s' of field '%s' has unaccepted content type '%s'", file.Filename, info.Name, file.Header.Get("Content-Type")),
+			}
+		}
+	}
+
+	switch fieldInfo.Type {
+	case fileHeaderSliceType:
+		fieldInfo.Field.Set(reflect.ValueOf(files))
+	case fileHeaderType:
+		fieldInfo.Field.Set(reflect.ValueOf(files[0]))
+	default:
+		file, err := files[0].Open()
+		if err != nil {
+			return fmt.Errorf("file '%s' of field '%s' cannot be opened", files[0].Filename, info.Name)
+		}
+		addCloser(ctx, file)
+		fieldInfo.Field.Set(reflect.ValueOf(file))
+	}
+	return nil
+}
+
+// matchContentType 判断content type是否在允许列表中, 支持 "image/*" 形式的通配
+func matchContentType(contentType string, accepts []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	for _, accept := range accepts {
+		if accept == mediaType || accept == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(accept, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(accept, "*")) {
+			return true
+		}
+	}
+	return false
+}
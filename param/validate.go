@@ -0,0 +1,320 @@
+package param
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/zhyeah/gin-autoreg/exception"
+)
+
+// 校验规则
+const (
+	RuleRequired = "required"
+	RuleType     = "type"
+	RuleInvalid  = "invalid"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleLen      = "len"
+	RuleRegex    = "regex"
+	RuleOneOf    = "oneof"
+	RuleEmail    = "email"
+	RuleURL      = "url"
+	RuleSort     = "sort"
+	RuleFilter   = "filter"
+)
+
+// ValidationError 字段校验错误
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (err *ValidationError) Error() string {
+	return err.Message
+}
+
+// ValidationErrors 字段校验错误的集合
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ToHTTPException 转换为400异常, 校验错误列表作为详细信息
+func (errs ValidationErrors) ToHTTPException() *exception.HTTPException {
+	return exception.NewWithDetails(http.StatusBadRequest, errs.Error(), errs, errs)
+}
+
+// validateRule 校验规则
+type validateRule struct {
+	Name string
+	Arg  string
+}
+
+var regexCache sync.Map
+
+// parseValidateTag 解析validate标签, regex规则会占用标签的剩余部分, 因此需要放在最后
+func parseValidateTag(tag string) ([]*validateRule, error) {
+	rules := make([]*validateRule, 0)
+	for tag != "" {
+		item := tag
+		if strings.HasPrefix(tag, RuleRegex+"=") {
+			tag = ""
+		} else if idx := strings.Index(tag, ","); idx >= 0 {
+			item, tag = tag[:idx], tag[idx+1:]
+		} else {
+			tag = ""
+		}
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		rule := &validateRule{Name: item}
+		if idx := strings.Index(item, "="); idx >= 0 {
+			rule.Name, rule.Arg = item[:idx], item[idx+1:]
+		}
+		switch rule.Name {
+		case RuleMin, RuleMax, RuleLen:
+			if _, err := strconv.ParseFloat(rule.Arg, 64); err != nil {
+				return nil, fmt.Errorf("validate rule '%s' has invalid argument '%s'", rule.Name, rule.Arg)
+			}
+		case RuleRegex:
+			if _, err := compileRegex(rule.Arg); err != nil {
+				return nil, fmt.Errorf("validate rule 'regex' has invalid pattern '%s'", rule.Arg)
+			}
+		case RuleOneOf:
+			if rule.Arg == "" {
+				return nil, fmt.Errorf("validate rule 'oneof' needs at least one option")
+			}
+		case RuleEmail, RuleURL:
+		default:
+			return nil, fmt.Errorf("unknown validate rule '%s'", rule.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if val, ok := regexCache.Load(pattern); ok {
+		return val.(*regexp.Regexp), nil
+	}
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, reg)
+	return reg, nil
+}
+
+// checkValidateTags 检查类型中所有validate标签是否合法, 用于注册路由时提前发现错误
+func checkValidateTags(typ reflect.Type, visited map[reflect.Type]bool) error {
+	typ = indirectType(typ)
+	if visited[typ] {
+		return nil
+	}
+	visited[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return checkValidateTags(typ.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			structField := typ.Field(i)
+			if structField.PkgPath != "" && !structField.Anonymous {
+				continue
+			}
+			if _, err := parseValidateTag(structField.Tag.Get("validate")); err != nil {
+				return fmt.Errorf("field '%s': %s", structField.Name, err.Error())
+			}
+			if err := checkValidateTags(structField.Type, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField 使用validate标签校验字段值
+func validateField(val reflect.Value, path string, tag string, errs *ValidationErrors) {
+	if tag == "" {
+		return
+	}
+	rules, err := parseValidateTag(tag)
+	if err != nil {
+		*errs = append(*errs, &ValidationError{Field: path, Rule: RuleInvalid, Message: err.Error()})
+		return
+	}
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	for _, rule := range rules {
+		if message, ok := checkRule(val, rule); !ok {
+			*errs = append(*errs, &ValidationError{
+				Field:   path,
+				Rule:    rule.Name,
+				Message: fmt.Sprintf("field '%s' %s", path, message),
+			})
+		}
+	}
+}
+
+// validateValue 递归校验结构体各字段的validate标签, path为json路径
+func validateValue(val reflect.Value, path string, naming NamingStrategy, errs *ValidationErrors) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			validateValue(val.Index(i), fmt.Sprintf("%s[%d]", path, i), naming, errs)
+		}
+	case reflect.Map:
+		rg := val.MapRange()
+		for rg.Next() {
+			validateValue(rg.Value(), joinParamKey(path, fmt.Sprint(rg.Key().Interface())), naming, errs)
+		}
+	case reflect.Struct:
+		valType := val.Type()
+		for i := 0; i < valType.NumField(); i++ {
+			structField := valType.Field(i)
+			if structField.PkgPath != "" && !structField.Anonymous {
+				continue
+			}
+			name := strings.Split(structField.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if structField.Anonymous && name == "" {
+				validateValue(val.Field(i), path, naming, errs)
+				continue
+			}
+			if name == "" {
+				name = naming.Name(structField.Name)
+			}
+			fieldPath := joinParamKey(path, name)
+			validateField(val.Field(i), fieldPath, structField.Tag.Get("validate"), errs)
+			validateValue(val.Field(i), fieldPath, naming, errs)
+		}
+	}
+}
+
+// checkRule 校验单个规则, 不通过时返回错误描述
+func checkRule(val reflect.Value, rule *validateRule) (string, bool) {
+	switch rule.Name {
+	case RuleMin, RuleMax:
+		limit, _ := strconv.ParseFloat(rule.Arg, 64)
+		size, isLength, ok := measure(val)
+		if !ok {
+			return fmt.Sprintf("of type %s does not support rule '%s'", val.Type(), rule.Name), false
+		}
+		desc := "must be"
+		if isLength {
+			desc = "length must be"
+		}
+		if rule.Name == RuleMin && size < limit {
+			return fmt.Sprintf("%s at least %s", desc, rule.Arg), false
+		}
+		if rule.Name == RuleMax && size > limit {
+			return fmt.Sprintf("%s at most %s", desc, rule.Arg), false
+		}
+	case RuleLen:
+		limit, _ := strconv.ParseFloat(rule.Arg, 64)
+		size, isLength, ok := measure(val)
+		if !ok || !isLength {
+			return fmt.Sprintf("of type %s does not support rule 'len'", val.Type()), false
+		}
+		if size != limit {
+			return fmt.Sprintf("length must be %s", rule.Arg), false
+		}
+	case RuleRegex:
+		str, ok := stringOf(val)
+		if !ok || str == "" {
+			return "", true
+		}
+		reg, _ := compileRegex(rule.Arg)
+		if !reg.MatchString(str) {
+			return fmt.Sprintf("must match '%s'", rule.Arg), false
+		}
+	case RuleOneOf:
+		str, ok := stringOf(val)
+		if !ok || (str == "" && val.Kind() == reflect.String) {
+			return "", true
+		}
+		options := strings.Split(rule.Arg, "|")
+		for _, option := range options {
+			if option == str {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", strings.Join(options, ", ")), false
+	case RuleEmail:
+		str, ok := stringOf(val)
+		if !ok || str == "" {
+			return "", true
+		}
+		if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+			return "must be a valid email", false
+		}
+	case RuleURL:
+		str, ok := stringOf(val)
+		if !ok || str == "" {
+			return "", true
+		}
+		if u, err := url.ParseRequestURI(str); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid url", false
+		}
+	}
+	return "", true
+}
+
+// measure 获取用于min/max比较的值, 数字取其值, 字符串, 切片和map取其长度
+func measure(val reflect.Value) (float64, bool, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(val.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(val.Len()), true, true
+	}
+	return 0, false, false
+}
+
+// stringOf 获取标量的字符串形式
+func stringOf(val reflect.Value) (string, bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return fmt.Sprint(val.Interface()), true
+	}
+	return "", false
+}
@@ -0,0 +1,287 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+// EventStreamContentType 服务器推送事件的Content-Type
+const EventStreamContentType = "text/event-stream"
+
+// eventSinkKey 当前请求的EventSink在gin.Context中的key
+const eventSinkKey = "autoroute.eventSink"
+
+var eventSinkType = reflect.TypeOf((*vo.EventSink)(nil)).Elem()
+
+// errStreamClosed 推送结束后继续发送事件时返回
+var errStreamClosed = errors.New("event stream is closed")
+
+// IsEventRoute 判断controller方法是否以text/event-stream响应: 返回可接收的通道, 或者参数中有vo.EventSink
+func IsEventRoute(methodType reflect.Type) bool {
+	if methodType.NumOut() > 1 && isEventChan(methodType.Out(0)) {
+		return true
+	}
+	for i := 0; i < methodType.NumIn(); i++ {
+		if methodType.In(i) == eventSinkType {
+			return true
+		}
+	}
+	return false
+}
+
+func isEventChan(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Chan && typ.ChanDir()&reflect.RecvDir != 0
+}
+
+// eventStream 向响应写入事件, 第一次写入时发送响应头, 写入时加锁以便心跳和事件可以在不同的goroutine中发送.
+// gin.Context在请求结束后会被复用, 心跳的goroutine只能在close之前通过它写入, 请求的context在创建时保存
+type eventStream struct {
+	ctx     *gin.Context
+	reqCtx  context.Context
+	lock    sync.Mutex
+	started bool
+	closed  bool
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func newEventStream(ctx *gin.Context) *eventStream {
+	return &eventStream{ctx: ctx, reqCtx: ctx.Request.Context(), stop: make(chan struct{})}
+}
+
+// start 发送响应头, 调用时需要持有锁
+func (stream *eventStream) start() {
+	if stream.started {
+		return
+	}
+	stream.started = true
+	header := stream.ctx.Writer.Header()
+	header.Set("Content-Type", EventStreamContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 关闭nginx等反向代理的缓冲
+	header.Set("X-Accel-Buffering", "no")
+	stream.ctx.Status(http.StatusOK)
+	stream.ctx.Writer.WriteHeaderNow()
+	stream.ctx.Writer.Flush()
+}
+
+// write 写入一段内容并flush
+func (stream *eventStream) write(content string) error {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if stream.closed {
+		return errStreamClosed
+	}
+	if err := stream.reqCtx.Err(); err != nil {
+		return err
+	}
+	stream.start()
+	if _, err := stream.ctx.Writer.WriteString(content); err != nil {
+		return err
+	}
+	stream.ctx.Writer.Flush()
+	return nil
+}
+
+// Send 实现vo.EventSink
+func (stream *eventStream) Send(data interface{}) error {
+	content, err := formatEvent(data)
+	if err != nil {
+		return err
+	}
+	return stream.write(content)
+}
+
+// Done 实现vo.EventSink
+func (stream *eventStream) Done() <-chan struct{} {
+	return stream.reqCtx.Done()
+}
+
+// heartbeat 定期发送注释行, 避免连接因空闲被代理断开
+func (stream *eventStream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if stream.write(": heartbeat\n\n") != nil {
+				return
+			}
+		case <-stream.stop:
+			return
+		case <-stream.Done():
+			return
+		}
+	}
+}
+
+// close 停止心跳, 之后不再写入
+func (stream *eventStream) close() {
+	stream.once.Do(func() {
+		close(stream.stop)
+		stream.lock.Lock()
+		defer stream.lock.Unlock()
+		stream.closed = true
+	})
+}
+
+// heartbeatOf 路由的心跳间隔, 为0时不发送心跳
+func heartbeatOf(ctx *gin.Context) time.Duration {
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil {
+		return httpRequest.Heartbeat
+	}
+	return 0
+}
+
+// EventSinkOf vo.EventSink的参数提供者, 同一个请求中返回同一个EventSink
+func EventSinkOf(ctx *gin.Context) (vo.EventSink, error) {
+	if val, ok := ctx.Get(eventSinkKey); ok {
+		return val.(*eventStream), nil
+	}
+	stream := newEventStream(ctx)
+	ctx.Set(eventSinkKey, stream)
+	if interval := heartbeatOf(ctx); interval > 0 {
+		go stream.heartbeat(interval)
+	}
+	return stream, nil
+}
+
+// FinishEvents 在controller返回后结束EventSink的推送, 请求没有使用EventSink时返回false.
+// 推送还没有开始时, 错误返回false交给ResponseHandler处理; 已经开始时, 错误作为error事件发送
+func FinishEvents(ctx *gin.Context, err error) bool {
+	val, ok := ctx.Get(eventSinkKey)
+	if !ok {
+		return false
+	}
+	stream := val.(*eventStream)
+	defer stream.close()
+	stream.lock.Lock()
+	started := stream.started
+	stream.lock.Unlock()
+	if err != nil {
+		if !started {
+			return false
+		}
+		stream.Send(&vo.Event{Name: "error", Data: err.Error()})
+		return true
+	}
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if !stream.closed {
+		stream.start()
+	}
+	return true
+}
+
+// CloseEvents 停止EventSink的心跳, 请求结束时调用, 可以重复调用
+func CloseEvents(ctx *gin.Context) {
+	if val, ok := ctx.Get(eventSinkKey); ok {
+		val.(*eventStream).close()
+	}
+}
+
+// StreamEvents 将通道中的值作为事件推送, 直到通道关闭或客户端断开连接, obj不是可接收的通道或为nil时返回false
+func StreamEvents(ctx *gin.Context, obj interface{}) bool {
+	if obj == nil {
+		return false
+	}
+	ch := reflect.ValueOf(obj)
+	if !isEventChan(ch.Type()) || ch.IsNil() {
+		return false
+	}
+
+	stream := newEventStream(ctx)
+	defer stream.close()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stream.Done())},
+		// 没有心跳时Chan为零值, 该case会被忽略
+		{Dir: reflect.SelectRecv},
+	}
+	if interval := heartbeatOf(ctx); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		cases[2].Chan = reflect.ValueOf(ticker.C)
+	}
+
+	stream.lock.Lock()
+	stream.start()
+	stream.lock.Unlock()
+	for {
+		chosen, val, ok := reflect.Select(cases)
+		switch {
+		case chosen == 0 && !ok, chosen == 1:
+			return true
+		case chosen == 0:
+			if stream.Send(val.Interface()) != nil {
+				return true
+			}
+		default:
+			if stream.write(": heartbeat\n\n") != nil {
+				return true
+			}
+		}
+	}
+}
+
+// formatEvent 将值序列化为事件, 数据中的每一行对应一个data字段
+func formatEvent(obj interface{}) (string, error) {
+	event := &vo.Event{Data: obj}
+	switch v := obj.(type) {
+	case vo.Event:
+		event = &v
+	case *vo.Event:
+		if v != nil {
+			event = v
+		}
+	}
+
+	var sb strings.Builder
+	if event.Name != "" {
+		sb.WriteString("event: " + eventLine(event.Name) + "\n")
+	}
+	if event.ID != "" {
+		sb.WriteString("id: " + eventLine(event.ID) + "\n")
+	}
+	if event.Retry > 0 {
+		sb.WriteString(fmt.Sprintf("retry: %d\n", event.Retry))
+	}
+
+	var content string
+	switch v := event.Data.(type) {
+	case nil:
+	case string:
+		content = v
+	case []byte:
+		content = string(v)
+	default:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		content = string(bytes)
+	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, line := range strings.Split(content, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+// eventLine 去掉事件名和ID中的换行, 换行会破坏事件的格式
+func eventLine(str string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(str)
+}
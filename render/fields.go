@@ -0,0 +1,207 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+)
+
+// 响应字段的去向
+const (
+	TO_HEADER = "header"
+	TO_STATUS = "status"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// liftedField 带有'to'标签, 需要写入响应头或状态码的字段.
+// 响应体不会被修改, 这些字段需要通过 json:"-" 从响应体中去掉
+type liftedField struct {
+	index []int
+	field string
+	to    string
+	name  string
+	typ   reflect.Type
+	json  string
+}
+
+var liftedFields sync.Map
+
+// liftedFieldsOf 解析响应结构体中带有'to'标签的字段, 匿名嵌入的导出结构体会被展开
+func liftedFieldsOf(typ reflect.Type) []*liftedField {
+	if val, ok := liftedFields.Load(typ); ok {
+		return val.([]*liftedField)
+	}
+	fields := make([]*liftedField, 0)
+	collectLiftedFields(typ, nil, &fields, map[reflect.Type]bool{typ: true})
+	liftedFields.Store(typ, fields)
+	return fields
+}
+
+func collectLiftedFields(typ reflect.Type, index []int, fields *[]*liftedField, visited map[reflect.Type]bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		// 未导出的嵌入结构体中的值无法读取, 不会展开
+		if structField.Anonymous && structField.PkgPath == "" {
+			embeddedType := structField.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct && !visited[embeddedType] {
+				visited[embeddedType] = true
+				collectLiftedFields(embeddedType, fieldIndex, fields, visited)
+				continue
+			}
+		}
+		to, ok := structField.Tag.Lookup("to")
+		if !ok || structField.PkgPath != "" {
+			continue
+		}
+		name := structField.Tag.Get("field")
+		if name == "" {
+			name = structField.Name
+		}
+		*fields = append(*fields, &liftedField{
+			index: fieldIndex,
+			field: structField.Name,
+			to:    to,
+			name:  name,
+			typ:   structField.Type,
+			json:  structField.Tag.Get("json"),
+		})
+	}
+}
+
+// structTypeOf 响应类型为结构体或其指针时返回结构体类型
+func structTypeOf(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ, typ.Kind() == reflect.Struct
+}
+
+// ResponseFields 解析响应类型中写入响应头或状态码的字段, 用于路由元数据
+func ResponseFields(typ reflect.Type) []*data.ResponseField {
+	ret := make([]*data.ResponseField, 0)
+	typ, ok := structTypeOf(typ)
+	if !ok {
+		return ret
+	}
+	for _, field := range liftedFieldsOf(typ) {
+		ret = append(ret, &data.ResponseField{
+			Field: field.field,
+			To:    field.to,
+			Name:  field.name,
+			Type:  field.typ.String(),
+		})
+	}
+	return ret
+}
+
+// CheckResponseFields 在注册路由时检查响应类型中'to'标签的字段:
+// 去向只能是header或status, 字段需要有 json:"-" 标签, status字段需要是整数
+func CheckResponseFields(typ reflect.Type) error {
+	typ, ok := structTypeOf(typ)
+	if !ok {
+		return nil
+	}
+	for _, field := range liftedFieldsOf(typ) {
+		if field.to != TO_HEADER && field.to != TO_STATUS {
+			return fmt.Errorf("unknown to '%s' of field '%s', it should be '%s' or '%s'", field.to, field.field, TO_HEADER, TO_STATUS)
+		}
+		if strings.Split(field.json, ",")[0] != "-" {
+			return fmt.Errorf("field '%s' is written into the %s, it needs a 'json:\"-\"' tag to be omitted from the body", field.field, field.to)
+		}
+		if field.to != TO_STATUS {
+			continue
+		}
+		switch field.typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		default:
+			return fmt.Errorf("status field '%s' should be an int", field.field)
+		}
+	}
+	return nil
+}
+
+// LiftFields 将响应结构体中带有'to'标签的字段写入响应头, 返回指定的状态码(0表示未指定)
+func LiftFields(ctx *gin.Context, obj interface{}) int {
+	if obj == nil {
+		return 0
+	}
+	val := reflect.ValueOf(obj)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return 0
+	}
+
+	status := 0
+	for _, field := range liftedFieldsOf(val.Type()) {
+		fieldVal, ok := fieldByIndex(val, field.index)
+		if !ok {
+			continue
+		}
+		switch field.to {
+		case TO_STATUS:
+			if fieldVal.Kind() >= reflect.Int && fieldVal.Kind() <= reflect.Int64 {
+				status = int(fieldVal.Int())
+			}
+		case TO_HEADER:
+			for _, headerVal := range headerValues(fieldVal) {
+				ctx.Writer.Header().Add(field.name, headerVal)
+			}
+		}
+	}
+	return status
+}
+
+// fieldByIndex 获取嵌套字段的值, 路径上有为nil的指针时返回false
+func fieldByIndex(val reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return reflect.Value{}, false
+			}
+			val = val.Elem()
+		}
+		val = val.Field(idx)
+	}
+	return val, true
+}
+
+// headerValues 将字段值转换为响应头的值, 切片对应多个值, 为nil的指针和空字符串会被忽略
+func headerValues(val reflect.Value) []string {
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Type() == timeType {
+		return []string{val.Interface().(time.Time).UTC().Format(http.TimeFormat)}
+	}
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8 {
+		ret := make([]string, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			ret = append(ret, headerValues(val.Index(i))...)
+		}
+		return ret
+	}
+	str := fmt.Sprint(val.Interface())
+	if str == "" {
+		return nil
+	}
+	return []string{str}
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+var (
+	fileType   = reflect.TypeOf(vo.File{})
+	rawType    = reflect.TypeOf(vo.Raw{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// IsStreamType 判断返回值类型是否直接写入响应体: vo.File, vo.Raw及其指针, []byte, 以及实现io.Reader的类型
+func IsStreamType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr && (typ.Elem() == fileType || typ.Elem() == rawType) {
+		return true
+	}
+	return typ == fileType || typ == rawType || typ == bytesType || typ.Implements(readerType)
+}
+
+// Stream 将文件, 原始数据([]byte视为vo.Raw)或io.Reader直接写入响应体, obj不是这些类型或为nil时返回false.
+// status为0时响应200, 状态码为200时可以Seek的文件支持Range和条件请求
+func Stream(ctx *gin.Context, status int, obj interface{}) bool {
+	if obj == nil {
+		return false
+	}
+	if val := reflect.ValueOf(obj); val.Kind() == reflect.Ptr && val.IsNil() {
+		return false
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	switch v := obj.(type) {
+	case vo.File:
+		streamFile(ctx, status, &v)
+	case *vo.File:
+		streamFile(ctx, status, v)
+	case vo.Raw:
+		writeRaw(ctx, status, &v)
+	case *vo.Raw:
+		writeRaw(ctx, status, v)
+	case []byte:
+		writeRaw(ctx, status, &vo.Raw{Data: v})
+	case *os.File:
+		file := vo.NewFile(filepath.Base(v.Name()), v)
+		if info, err := v.Stat(); err == nil {
+			file.Size, file.ModTime = info.Size(), info.ModTime()
+		}
+		streamFile(ctx, status, file)
+	case io.Reader:
+		streamFile(ctx, status, &vo.File{Reader: v})
+	default:
+		return false
+	}
+	return true
+}
+
+// streamFile 写入文件, 状态码为200且可以Seek时交给http.ServeContent处理Range和条件请求
+func streamFile(ctx *gin.Context, status int, file *vo.File) {
+	reader := file.Reader
+	if reader == nil {
+		reader = bytes.NewReader(nil)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	header := ctx.Writer.Header()
+	if file.Name != "" {
+		disposition := "attachment"
+		if file.Inline {
+			disposition = "inline"
+		}
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}))
+	}
+	contentType := file.ContentType
+	if contentType == "" && file.Name != "" {
+		contentType = mime.TypeByExtension(filepath.Ext(file.Name))
+	}
+
+	seeker, seekable := reader.(io.ReadSeeker)
+	if seekable && status == http.StatusOK {
+		// Content-Type为空时由ServeContent根据内容推断
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		http.ServeContent(ctx.Writer, ctx.Request, file.Name, file.ModTime, seeker)
+		return
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	size := file.Size
+	if size == 0 && seekable {
+		size = seekSize(seeker)
+	}
+	if size > 0 || seekable {
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	if !file.ModTime.IsZero() {
+		header.Set("Last-Modified", file.ModTime.UTC().Format(http.TimeFormat))
+	}
+	ctx.Status(status)
+	ctx.Writer.WriteHeaderNow()
+	if ctx.Request.Method != http.MethodHead {
+		io.Copy(ctx.Writer, reader)
+	}
+}
+
+// seekSize 通过Seek获取剩余内容的大小, 并恢复读取位置
+func seekSize(seeker io.Seeker) int64 {
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0
+	}
+	return end - current
+}
+
+// writeRaw 写入原始数据, ContentType为空时由数据内容推断
+func writeRaw(ctx *gin.Context, status int, raw *vo.Raw) {
+	contentType := raw.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(raw.Data)
+	}
+	ctx.Header("Content-Length", strconv.Itoa(len(raw.Data)))
+	ctx.Data(status, contentType, raw.Data)
+}
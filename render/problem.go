@@ -0,0 +1,64 @@
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/exception"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+// ProblemContentType problem+json的媒体类型
+const ProblemContentType = "application/problem+json"
+
+// ErrorStatus 异常对应的HTTP状态码, 错误码为4xx或5xx时直接使用, 否则为500
+func ErrorStatus(exp *exception.HTTPException) int {
+	if exp.Code >= 400 && exp.Code <= 599 {
+		return exp.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// NewProblem 由异常生成problem, 详细信息为列表(如参数校验错误)时作为errors成员, 否则作为details扩展成员,
+// 异常的错误码和Extensions也作为扩展成员
+func NewProblem(ctx *gin.Context, exp *exception.HTTPException) *vo.Problem {
+	status := ErrorStatus(exp)
+	problem := &vo.Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     exp.Message,
+		Instance:   ctx.Request.URL.RequestURI(),
+		Extensions: map[string]interface{}{"code": exp.Code},
+	}
+	if exp.Details != nil {
+		if isList(exp.Details) {
+			problem.Errors = exp.Details
+		} else {
+			problem.Extensions["details"] = exp.Details
+		}
+	}
+	for key, val := range exp.Extensions {
+		problem.Extensions[key] = val
+	}
+	return problem
+}
+
+// Problem 以application/problem+json格式响应异常
+func Problem(ctx *gin.Context, exp *exception.HTTPException) {
+	problem := NewProblem(ctx, exp)
+	bts, err := json.Marshal(problem)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	ctx.Data(problem.Status, ProblemContentType, bts)
+}
+
+// isList 判断值是否为切片或数组
+func isList(val interface{}) bool {
+	kind := reflect.ValueOf(val).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
@@ -0,0 +1,306 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
+	"gopkg.in/yaml.v2"
+)
+
+// MediaType Accept中的一个媒体类型, 如 application/json; pretty=true; q=0.9
+type MediaType struct {
+	Type    string
+	Params  map[string]string
+	Quality float64
+}
+
+// Renderer 响应渲染器, accept为协商得到的Accept中的媒体类型, 可以从其参数中读取选项
+type Renderer interface {
+	Render(ctx *gin.Context, code int, obj interface{}, accept *MediaType)
+}
+
+// RendererFunc 函数形式的响应渲染器
+type RendererFunc func(ctx *gin.Context, code int, obj interface{}, accept *MediaType)
+
+// Render 渲染响应
+func (f RendererFunc) Render(ctx *gin.Context, code int, obj interface{}, accept *MediaType) {
+	f(ctx, code, obj, accept)
+}
+
+var renderersLock sync.RWMutex
+var renderers = map[string]Renderer{}
+
+// mediaTypes 按注册顺序排列的媒体类型, 客户端接受任意类型时使用第一个
+var mediaTypes = make([]string, 0)
+
+// aliases 路由produces中可以使用的简称
+var aliases = map[string]string{
+	"json":    "application/json",
+	"xml":     "application/xml",
+	"yaml":    "application/x-yaml",
+	"msgpack": "application/msgpack",
+}
+
+// 内置渲染器的Content-Type
+const (
+	jsonContentType    = "application/json; charset=utf-8"
+	xmlContentType     = "application/xml; charset=utf-8"
+	yamlContentType    = "application/x-yaml; charset=utf-8"
+	msgpackContentType = "application/msgpack"
+)
+
+func init() {
+	RegisterRenderer("application/json", RendererFunc(func(ctx *gin.Context, code int, obj interface{}, accept *MediaType) {
+		var bts []byte
+		var err error
+		if pretty, _ := strconv.ParseBool(accept.Params["pretty"]); pretty {
+			bts, err = json.MarshalIndent(obj, "", "    ")
+		} else {
+			bts, err = json.Marshal(obj)
+		}
+		writeEncoded(ctx, code, obj, jsonContentType, bts, err)
+	}))
+
+	xmlRenderer := RendererFunc(func(ctx *gin.Context, code int, obj interface{}, accept *MediaType) {
+		bts, err := xml.Marshal(obj)
+		writeEncoded(ctx, code, obj, xmlContentType, bts, err)
+	})
+	RegisterRenderer("application/xml", xmlRenderer)
+	RegisterRenderer("text/xml", xmlRenderer)
+
+	yamlRenderer := RendererFunc(func(ctx *gin.Context, code int, obj interface{}, accept *MediaType) {
+		bts, err := marshalYAML(obj)
+		writeEncoded(ctx, code, obj, yamlContentType, bts, err)
+	})
+	RegisterRenderer("application/x-yaml", yamlRenderer)
+	RegisterRenderer("application/yaml", yamlRenderer)
+	RegisterRenderer("text/yaml", yamlRenderer)
+
+	msgpackRenderer := RendererFunc(func(ctx *gin.Context, code int, obj interface{}, accept *MediaType) {
+		var bts []byte
+		var handle codec.MsgpackHandle
+		err := codec.NewEncoderBytes(&bts, &handle).Encode(obj)
+		writeEncoded(ctx, code, obj, msgpackContentType, bts, err)
+	})
+	RegisterRenderer("application/msgpack", msgpackRenderer)
+	RegisterRenderer("application/x-msgpack", msgpackRenderer)
+}
+
+// marshalYAML 序列化为yaml, yaml.v2对不支持的类型会panic, 转换为错误返回
+func marshalYAML(obj interface{}) (bts []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("yaml: %v", r)
+		}
+	}()
+	return yaml.Marshal(obj)
+}
+
+// writeEncoded 写入已经序列化的响应. 序列化失败时, 如果路由可以产生且客户端接受JSON,
+// 在写入任何内容之前改用JSON, 否则响应500
+func writeEncoded(ctx *gin.Context, code int, obj interface{}, contentType string, bts []byte, err error) {
+	if err == nil {
+		ctx.Data(code, contentType, bts)
+		return
+	}
+	if contentType != jsonContentType && acceptsJSON(ctx) {
+		if jsonBytes, jsonErr := json.Marshal(obj); jsonErr == nil {
+			ctx.Data(code, jsonContentType, jsonBytes)
+			return
+		}
+	}
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	ctx.String(http.StatusInternalServerError, "render response as %s failed: %s", mediaType, err.Error())
+}
+
+// acceptsJSON 判断路由可以产生JSON, 并且Accept请求头接受JSON
+func acceptsJSON(ctx *gin.Context) bool {
+	produced := false
+	for _, mediaType := range Produces(ctx) {
+		if mediaType == "application/json" {
+			produced = true
+			break
+		}
+	}
+	if !produced {
+		return false
+	}
+	for _, accept := range ParseAccept(ctx.GetHeader("Accept")) {
+		if accept.Quality > 0 && matchMediaType(accept.Type, "application/json") {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterRenderer 注册媒体类型对应的渲染器, 已注册的媒体类型会被覆盖
+func RegisterRenderer(mediaType string, renderer Renderer) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	renderersLock.Lock()
+	defer renderersLock.Unlock()
+	if _, ok := renderers[mediaType]; !ok {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	renderers[mediaType] = renderer
+}
+
+// GetRenderer 获取媒体类型对应的渲染器
+func GetRenderer(mediaType string) (Renderer, bool) {
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+	renderer, ok := renderers[strings.ToLower(mediaType)]
+	return renderer, ok
+}
+
+// ParseProduces 解析路由的produces, 多个媒体类型以逗号分隔, 可以使用json, xml, yaml, msgpack简称
+func ParseProduces(produces string) ([]string, error) {
+	ret := make([]string, 0)
+	for _, mediaType := range strings.Split(produces, ",") {
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		if alias, ok := aliases[mediaType]; ok {
+			mediaType = alias
+		}
+		if _, ok := GetRenderer(mediaType); !ok {
+			return nil, fmt.Errorf("no renderer for media type '%s'", mediaType)
+		}
+		ret = append(ret, mediaType)
+	}
+	return ret, nil
+}
+
+// ParseAccept 解析Accept请求头, 按照q值和具体程度从高到低排序, 为空时视为 */*
+func ParseAccept(accept string) []*MediaType {
+	ret := make([]*MediaType, 0)
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(item, ";")
+		mediaType := &MediaType{
+			Type:    strings.ToLower(strings.TrimSpace(parts[0])),
+			Params:  make(map[string]string),
+			Quality: 1,
+		}
+		if mediaType.Type == "" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			kv := strings.SplitN(param, "=", 2)
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			val := ""
+			if len(kv) == 2 {
+				val = strings.Trim(strings.TrimSpace(kv[1]), "\"")
+			}
+			if key == "q" {
+				if q, err := strconv.ParseFloat(val, 64); err == nil {
+					mediaType.Quality = q
+				}
+				continue
+			}
+			mediaType.Params[key] = val
+		}
+		ret = append(ret, mediaType)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Quality != ret[j].Quality {
+			return ret[i].Quality > ret[j].Quality
+		}
+		return specificity(ret[i].Type) > specificity(ret[j].Type)
+	})
+	return ret
+}
+
+// specificity 媒体类型的具体程度, */* 最低
+func specificity(mediaType string) int {
+	if mediaType == "*/*" {
+		return 0
+	}
+	if strings.HasSuffix(mediaType, "/*") {
+		return 1
+	}
+	return 2
+}
+
+// matchMediaType 判断Accept中的媒体类型是否接受目标类型
+func matchMediaType(pattern string, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// Produces 路由可以产生的媒体类型, 路由没有指定produces时为全部已注册的类型
+func Produces(ctx *gin.Context) []string {
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil && len(httpRequest.Produces) > 0 {
+		return httpRequest.Produces
+	}
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+	return append([]string{}, mediaTypes...)
+}
+
+// Negotiate 根据Accept请求头和路由的produces选择媒体类型及其渲染器, 没有可以接受的类型时返回false
+func Negotiate(ctx *gin.Context) (string, *MediaType, Renderer, bool) {
+	produces := Produces(ctx)
+	for _, accept := range ParseAccept(ctx.GetHeader("Accept")) {
+		if accept.Quality <= 0 {
+			continue
+		}
+		for _, mediaType := range produces {
+			if !matchMediaType(accept.Type, mediaType) {
+				continue
+			}
+			if renderer, ok := GetRenderer(mediaType); ok {
+				return mediaType, accept, renderer, true
+			}
+		}
+	}
+	return "", nil, nil, false
+}
+
+// Render 按照内容协商的结果渲染响应, 没有可以接受的类型时响应406
+func Render(ctx *gin.Context, code int, obj interface{}) {
+	_, accept, renderer, ok := Negotiate(ctx)
+	if !ok {
+		NotAcceptable(ctx)
+		return
+	}
+	renderer.Render(ctx, code, obj, accept)
+}
+
+// RenderError 渲染错误响应, 没有可以接受的类型时(包括406本身和不进行内容协商的路由)使用第一个可以产生的类型
+func RenderError(ctx *gin.Context, code int, obj interface{}) {
+	_, accept, renderer, ok := Negotiate(ctx)
+	if !ok {
+		mediaType := Produces(ctx)[0]
+		renderer, _ = GetRenderer(mediaType)
+		accept = &MediaType{Type: mediaType, Params: make(map[string]string), Quality: 1}
+	}
+	renderer.Render(ctx, code, obj, accept)
+}
+
+// NotAcceptableError 没有可以接受的响应类型时的异常, 列出可以产生的媒体类型
+func NotAcceptableError(ctx *gin.Context) *exception.HTTPException {
+	return exception.New(http.StatusNotAcceptable, fmt.Sprintf("not acceptable, available media types: %s", strings.Join(Produces(ctx), ", ")), nil)
+}
+
+// NotAcceptable 以纯文本响应406, 不使用ResponseHandler时可以使用
+func NotAcceptable(ctx *gin.Context) {
+	ctx.String(http.StatusNotAcceptable, NotAcceptableError(ctx).Message)
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestRenderEncodeFailure(t *testing.T) {
+	cases := []struct {
+		name        string
+		accept      string
+		produces    []string
+		code        int
+		contentType string
+		body        string
+	}{
+		{name: "xml only", accept: "application/xml", code: 500, contentType: "text/plain; charset=utf-8", body: "render response as application/xml failed"},
+		{name: "json also accepted", accept: "application/xml, application/json;q=0.5", code: 200, contentType: jsonContentType, body: `{"a":1}`},
+		{name: "any type accepted", accept: "application/xml, */*;q=0.1", code: 200, contentType: jsonContentType, body: `{"a":1}`},
+		{name: "json refused", accept: "application/xml, application/json;q=0", code: 500, contentType: "text/plain; charset=utf-8", body: "render response as application/xml failed"},
+		{name: "json not produced", accept: "application/xml, application/json", produces: []string{"application/xml"}, code: 500, contentType: "text/plain; charset=utf-8", body: "render response as application/xml failed"},
+		{name: "msgpack", accept: "application/msgpack", code: 200, contentType: "application/msgpack"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rec)
+			ctx.Request = httptest.NewRequest("GET", "/", nil)
+			ctx.Request.Header.Set("Accept", c.accept)
+			if c.produces != nil {
+				ctx.Set(data.HTTPRequestKey, &data.HTTPRequest{Produces: c.produces})
+			}
+			Render(ctx, 200, map[string]int{"a": 1})
+			if rec.Code != c.code || rec.Header().Get("Content-Type") != c.contentType || !strings.Contains(rec.Body.String(), c.body) {
+				t.Fatalf("got %d %s %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package autoroute
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/data"
+	"github.com/zhyeah/gin-autoreg/exception"
+	"github.com/zhyeah/gin-autoreg/param"
+	"github.com/zhyeah/gin-autoreg/render"
+	"github.com/zhyeah/gin-autoreg/vo"
+)
+
+// StatusMode 响应HTTP状态码的模式
+type StatusMode int
+
+const (
+	// StatusModeLegacy 总是响应200, 错误码只体现在响应体中
+	StatusModeLegacy StatusMode = iota
+	// StatusModeHTTP 响应真实的HTTP状态码: 错误码为4xx或5xx时作为状态码, 其他错误为500;
+	// 成功时为路由'status'指定的状态码, 没有指定时数据为nil响应204, 否则响应200
+	StatusModeHTTP
+)
+
+func init() {
+	// controller参数中的vo.EventSink由render提供
+	if err := param.RegisterProvider(render.EventSinkOf); err != nil {
+		panic(err)
+	}
+}
+
+// resultStatusKey controller返回值指定的状态码在gin.Context中的key
+const resultStatusKey = "autoroute.resultStatus"
+
+// ErrorFormat 默认ResponseHandler的错误响应格式
+type ErrorFormat int
+
+const (
+	// ErrorFormatGeneral 使用vo.GeneralResponse, 错误码在retCode中
+	ErrorFormatGeneral ErrorFormat = iota
+	// ErrorFormatProblem 使用RFC 7807的application/problem+json, 总是响应真实的HTTP状态码
+	ErrorFormatProblem
+)
+
+// ResponseStatus 按照状态码模式计算响应的HTTP状态码, 自定义的ResponseHandler也可以使用
+func (router *AutoRouter) ResponseStatus(ctx *gin.Context, exp *exception.HTTPException, obj interface{}) int {
+	if exp == nil {
+		if status := ctx.GetInt(resultStatusKey); status != 0 {
+			return status
+		}
+	}
+	if router.AutoRouteConfig == nil || router.AutoRouteConfig.StatusMode != StatusModeHTTP {
+		return http.StatusOK
+	}
+	if exp != nil {
+		return render.ErrorStatus(exp)
+	}
+	if httpRequest := data.RouteOf(ctx); httpRequest != nil && httpRequest.SuccessStatus != 0 {
+		return httpRequest.SuccessStatus
+	}
+	if isNilData(obj) {
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// isNilData 判断响应数据是否为nil, 包括为nil的指针, 为nil的切片和map仍然视为空的列表
+func isNilData(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	val := reflect.ValueOf(data)
+	return val.Kind() == reflect.Ptr && val.IsNil()
+}
+
+// applyResult 应用controller返回值中的状态码, 响应头和cookie, 返回交给ResponseHandler的数据.
+// 状态码会同时设置到ctx.Writer上, 不在100到599之间时返回错误
+func applyResult(ctx *gin.Context, data interface{}) (interface{}, error) {
+	if result, ok := data.(vo.Result); ok {
+		data = &result
+	}
+	if isNilData(data) {
+		return data, nil
+	}
+	if headerSetter, ok := data.(vo.HeaderSetter); ok {
+		headerSetter.SetHeaders(ctx.Writer.Header())
+	}
+	if cookieSetter, ok := data.(vo.CookieSetter); ok {
+		for _, cookie := range cookieSetter.Cookies() {
+			http.SetCookie(ctx.Writer, cookie)
+		}
+	}
+	if statusCoder, ok := data.(vo.StatusCoder); ok && statusCoder.StatusCode() != 0 {
+		if err := setResultStatus(ctx, statusCoder.StatusCode()); err != nil {
+			return nil, err
+		}
+	}
+	if result, ok := data.(*vo.Result); ok {
+		data = result.Data
+	}
+	// 带有'to'标签的字段写入响应头和状态码
+	if status := render.LiftFields(ctx, data); status != 0 {
+		if err := setResultStatus(ctx, status); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// setResultStatus 记录controller返回值指定的状态码, 并设置到ctx.Writer上, 使自定义的ResponseHandler也可以读取
+func setResultStatus(ctx *gin.Context, status int) error {
+	if status < 100 || status > 599 {
+		return fmt.Errorf("invalid response status %d", status)
+	}
+	ctx.Set(resultStatusKey, status)
+	ctx.Status(status)
+	return nil
+}
+
+// setPageHeaders 返回分页响应时设置X-Total-Count和Link响应头
+func setPageHeaders(ctx *gin.Context, data interface{}) {
+	var resp *vo.PagedResponse
+	switch v := data.(type) {
+	case *vo.PagedResponse:
+		resp = v
+	case vo.PagedResponse:
+		resp = &v
+	}
+	if resp == nil {
+		return
+	}
+	ctx.Header("X-Total-Count", strconv.FormatInt(resp.Total, 10))
+	if links := param.PageLinks(ctx.Request.URL, resp); links != "" {
+		ctx.Header("Link", links)
+	}
+}
@@ -9,13 +9,16 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhyeah/gin-autoreg/container"
 	"github.com/zhyeah/gin-autoreg/controller"
 	"github.com/zhyeah/gin-autoreg/data"
 	"github.com/zhyeah/gin-autoreg/exception"
 	"github.com/zhyeah/gin-autoreg/intercepter"
 	"github.com/zhyeah/gin-autoreg/param"
+	"github.com/zhyeah/gin-autoreg/render"
 	"github.com/zhyeah/gin-autoreg/tag"
 	"github.com/zhyeah/gin-autoreg/util"
 	"github.com/zhyeah/gin-autoreg/vo"
@@ -29,12 +32,18 @@ const (
 )
 
 const (
-	TagFieldUrl    = "url"
-	TagFieldMethod = "method"
-	TagFieldFunc   = "func"
-	TagFieldAuth   = "auth"
-	TagFieldAuthor = "author"
-	TagFieldPrefix = "prefix"
+	TagFieldUrl       = "url"
+	TagFieldMethod    = "method"
+	TagFieldFunc      = "func"
+	TagFieldAuth      = "auth"
+	TagFieldAuthor    = "author"
+	TagFieldPrefix    = "prefix"
+	TagFieldMaxBody   = "maxBody"
+	TagFieldStrict    = "strict"
+	TagFieldCoerce    = "coerce"
+	TagFieldProduces  = "produces"
+	TagFieldStatus    = "status"
+	TagFieldHeartbeat = "heartbeat"
 )
 
 // AutoRouteConfig regitster route automatically
@@ -43,6 +52,25 @@ type AutoRouteConfig struct {
 	BaseUrl         string
 	ResponseHandler func(ctx *gin.Context, exp *exception.HTTPException, data interface{})
 	OAAuth          func(ctx *gin.Context, forceCheck bool)
+	// MaxBodySize max size of request body in bytes, 0 means no limit, can be overridden by 'maxBody' of route tag
+	MaxBodySize int64
+	// MissingContextCode the code responded when a required 'context' param is missing, default is 500
+	MissingContextCode int
+	// StrictBody reject unknown fields and mismatched types of json body, can be overridden by 'strict' of route tag
+	StrictBody bool
+	// CoerceBody allow converting between string and number of json body in strict mode, can be overridden by 'coerce' of route tag
+	CoerceBody bool
+	// Naming naming strategy deriving param names from field names without 'field' tag, e.g. param.SnakeCase, default is param.LowerCamelCase
+	Naming param.NamingStrategy
+	// StatusMode how the http status is responded, default is StatusModeLegacy which always responds 200
+	StatusMode StatusMode
+	// ErrorFormat the format of error responses of the default ResponseHandler, default is ErrorFormatGeneral
+	ErrorFormat ErrorFormat
+	// EventHeartbeat interval of heartbeat comments of event stream routes, 0 means no heartbeat, can be overridden by 'heartbeat' of route tag
+	EventHeartbeat time.Duration
+
+	// defaultResponseHandler the ResponseHandler is the default one installed by RegisterRoute
+	defaultResponseHandler bool
 }
 
 var autoRouter *AutoRouter
@@ -75,14 +103,25 @@ func (router *AutoRouter) AddFinishedAction(finishedAction func(*data.RouterCont
 func (router *AutoRouter) RegisterRoute(config *AutoRouteConfig) error {
 	router.AutoRouteConfig = config
 	if config.ResponseHandler == nil {
+		config.defaultResponseHandler = true
 		config.ResponseHandler = func(ctx *gin.Context, exp *exception.HTTPException, data interface{}) {
+			if exp != nil && config.ErrorFormat == ErrorFormatProblem {
+				render.Problem(ctx, exp)
+				return
+			}
+			status := router.ResponseStatus(ctx, exp, data)
+			if status == http.StatusNoContent {
+				ctx.Status(status)
+				return
+			}
 			if exp != nil {
-				ctx.JSON(http.StatusOK, vo.GeneralResponse{
+				render.RenderError(ctx, status, vo.GeneralResponse{
 					Code:    exp.Code,
 					Message: exp.Message,
+					Data:    exp.Details,
 				})
 			} else {
-				ctx.JSON(http.StatusOK, vo.GeneralResponse{
+				render.Render(ctx, status, vo.GeneralResponse{
 					Code:    0,
 					Message: "",
 					Data:    data,
@@ -96,9 +135,10 @@ func (router *AutoRouter) RegisterRoute(config *AutoRouteConfig) error {
 		}
 	}
 
-	// default route
-	var defaultController controller.DefaultController
-	config.Engine.NoRoute(defaultController.MethodNotFound)
+	// default route, responded by the ResponseHandler so that it follows StatusMode and ErrorFormat
+	config.Engine.NoRoute(func(ctx *gin.Context) {
+		config.ResponseHandler(ctx, exception.New(http.StatusNotFound, "API is not exist", nil), nil)
+	})
 
 	// base url
 	route := config.Engine.Group("")
@@ -106,8 +146,14 @@ func (router *AutoRouter) RegisterRoute(config *AutoRouteConfig) error {
 	// on start
 	router.onStart()
 
+	// inject dependencies and init each controller
+	err := router.initEachController()
+	if err != nil {
+		return err
+	}
+
 	// register route of each controller
-	err := router.registerEachController(route)
+	err = router.registerEachController(route)
 	if err != nil {
 		return err
 	}
@@ -136,6 +182,24 @@ func (router *AutoRouter) onFinished() {
 	}
 }
 
+// initEachController 注入controller的依赖, 并调用其Init方法, 再次注册路由时已经初始化的controller和单例不会重复初始化.
+// 初始化失败时, 已经初始化的controller和单例会按逆序关闭
+func (router *AutoRouter) initEachController() error {
+	dependencyContainer := container.GetContainer()
+	for k, v := range controller.ControllerMap {
+		if err := dependencyContainer.Prepare(v); err != nil {
+			dependencyContainer.Close()
+			return fmt.Errorf(k + " init failed, err: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Close 按初始化的逆序关闭实现了io.Closer的controller和容器中的单例
+func (router *AutoRouter) Close() error {
+	return container.GetContainer().Close()
+}
+
 func (router *AutoRouter) registerEachController(engine *gin.RouterGroup) error {
 
 	for k, v := range controller.ControllerMap {
@@ -173,6 +237,11 @@ func (router *AutoRouter) registerController(engine *gin.RouterGroup, ctrl inter
 
 	args := []interface{}{httpRequest.URL}
 
+	// route info, used by params resolving
+	args = append(args, func(ctx *gin.Context) {
+		ctx.Set(data.HTTPRequestKey, httpRequest)
+	})
+
 	// auth check
 	if router.AutoRouteConfig.OAAuth != nil {
 		args = append(args, func(ctx *gin.Context) {
@@ -221,10 +290,25 @@ func (router *AutoRouter) registerController(engine *gin.RouterGroup, ctrl inter
 			}
 		}()
 
+		defer param.ReleaseParams(ctx)
+		defer render.CloseEvents(ctx)
+
+		// 没有可以接受的响应类型时不再调用controller, 直接写入响应体的路由不进行内容协商.
+		// 自定义的ResponseHandler可能不使用内容协商, 只有路由声明了produces时才检查
+		negotiated := router.AutoRouteConfig.defaultResponseHandler || len(httpRequest.Produces) > 0
+		if _, _, _, ok := render.Negotiate(ctx); negotiated && !ok && !httpRequest.Stream {
+			router.AutoRouteConfig.ResponseHandler(ctx, render.NotAcceptableError(ctx), nil)
+			return
+		}
+
 		var err interface{} = nil
 		args, err := param.ResolveParams(ctrl, httpRequest.Func, ctx)
 		ctx.Set("args", args)
 		if err != nil {
+			if httpException, ok := err.(*exception.HTTPException); ok {
+				router.AutoRouteConfig.ResponseHandler(ctx, httpException, nil)
+				return
+			}
 			router.AutoRouteConfig.ResponseHandler(ctx, &exception.HTTPException{
 				Code:    http.StatusBadRequest,
 				Message: err.(error).Error(),
@@ -245,14 +329,39 @@ func (router *AutoRouter) registerController(engine *gin.RouterGroup, ctrl inter
 			err = rets[1]
 		}
 
+		// 使用EventSink的路由在controller返回后结束推送
+		if exp, _ := err.(error); render.FinishEvents(ctx, exp) {
+			return
+		}
+
 		if err == nil {
+			// 返回通道时将其中的值作为事件推送
+			if render.StreamEvents(ctx, data) {
+				return
+			}
+			var resultErr error
+			if data, resultErr = applyResult(ctx, data); resultErr != nil {
+				router.AutoRouteConfig.ResponseHandler(ctx, &exception.HTTPException{
+					Code:    http.StatusInternalServerError,
+					Message: resultErr.Error(),
+					Err:     resultErr,
+				}, nil)
+				return
+			}
+			// 文件和原始数据直接写入响应体, 不经过ResponseHandler
+			if render.Stream(ctx, router.ResponseStatus(ctx, nil, data), data) {
+				return
+			}
+			setPageHeaders(ctx, data)
 			router.AutoRouteConfig.ResponseHandler(ctx, nil, data)
 		} else if reflect.TypeOf(err).Elem().Name() == "HTTPException" {
 			httpException := err.(*exception.HTTPException)
 			router.AutoRouteConfig.ResponseHandler(ctx, &exception.HTTPException{
-				Code:    httpException.Code,
-				Message: httpException.Message,
-				Err:     httpException,
+				Code:       httpException.Code,
+				Message:    httpException.Message,
+				Err:        httpException,
+				Details:    httpException.Details,
+				Extensions: httpException.Extensions,
 			}, nil)
 		} else {
 			err := err.(error)
@@ -352,25 +461,112 @@ func (router *AutoRouter) convertTag(ctrl interface{}, tags []string) (*data.HTT
 	if !ok {
 		author = ""
 	}
-	dataStr, err := param.ResolvePostDataJson(ctrl, function)
+	dataStr, err := param.ResolvePostDataJsonWithNaming(ctrl, function, router.AutoRouteConfig.Naming)
 	if err != nil {
 		return nil, err
 	}
+	uploads, err := param.ResolveUploads(ctrl, function, router.AutoRouteConfig.Naming)
+	if err != nil {
+		return nil, err
+	}
+	params, err := param.ResolveParamInfos(ctrl, function, router.AutoRouteConfig.Naming)
+	if err != nil {
+		return nil, err
+	}
+	responseFields := make([]*data.ResponseField, 0)
+	stream := false
+	if methodType := reflect.ValueOf(ctrl).MethodByName(function).Type(); methodType.NumOut() > 1 {
+		if err := render.CheckResponseFields(methodType.Out(0)); err != nil {
+			return nil, err
+		}
+		responseFields = render.ResponseFields(methodType.Out(0))
+		stream = render.IsStreamType(methodType.Out(0))
+	}
+	events := render.IsEventRoute(reflect.ValueOf(ctrl).MethodByName(function).Type())
+	if err := param.CheckParams(ctrl, function, router.AutoRouteConfig.Naming); err != nil {
+		return nil, err
+	}
+
+	maxBodySize := router.AutoRouteConfig.MaxBodySize
+	if maxBody, ok := tagMap[TagFieldMaxBody]; ok {
+		maxBodySize, err = util.ParseByteSize(maxBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxBody '%s'", maxBody)
+		}
+	}
+
+	strictBody := router.AutoRouteConfig.StrictBody
+	if strict, ok := tagMap[TagFieldStrict]; ok {
+		strictBody = util.ConvertStringToBoolDefault(strict, strictBody)
+	}
+	coerceBody := router.AutoRouteConfig.CoerceBody
+	if coerce, ok := tagMap[TagFieldCoerce]; ok {
+		coerceBody = util.ConvertStringToBoolDefault(coerce, coerceBody)
+	}
+
+	produces, err := render.ParseProduces(tagMap[TagFieldProduces])
+	if err != nil {
+		return nil, err
+	}
+	successStatus := 0
+	if status, ok := tagMap[TagFieldStatus]; ok {
+		successStatus, err = util.ConvertStringToInt(status)
+		if err != nil || successStatus < 200 || successStatus > 299 {
+			return nil, fmt.Errorf("invalid status '%s', it should be a 2xx status", status)
+		}
+	}
+
+	heartbeat := router.AutoRouteConfig.EventHeartbeat
+	if interval, ok := tagMap[TagFieldHeartbeat]; ok {
+		heartbeat, err = time.ParseDuration(interval)
+		if err != nil || heartbeat < 0 {
+			return nil, fmt.Errorf("invalid heartbeat '%s'", interval)
+		}
+	}
 
 	if prefix == "true" {
 		url = router.AutoRouteConfig.BaseUrl + url
 	}
 
 	return &data.HTTPRequest{
-		URL:    url,
-		Method: method,
-		Func:   function,
-		Auth:   util.ConvertStringToBoolDefault(needAuth, true),
-		Author: author,
-		Data:   dataStr,
+		URL:                url,
+		Method:             method,
+		Func:               function,
+		Auth:               util.ConvertStringToBoolDefault(needAuth, true),
+		Author:             author,
+		Data:               dataStr,
+		Uploads:            uploads,
+		Params:             params,
+		MaxBodySize:        maxBodySize,
+		MissingContextCode: router.AutoRouteConfig.MissingContextCode,
+		StrictBody:         strictBody,
+		CoerceBody:         coerceBody,
+		Produces:           produces,
+		SuccessStatus:      successStatus,
+		ResponseFields:     responseFields,
+		Stream:             stream || events,
+		Events:             events,
+		Heartbeat:          heartbeat,
+		Naming:             router.AutoRouteConfig.Naming,
 	}, nil
 }
 
+// Register 注册单例, controller中带有 inject:"" 标签且类型匹配的字段会在RegisterRoute时被注入
+func Register(obj interface{}) {
+	container.GetContainer().Register(obj)
+}
+
+// RegisterNamed 注册具名单例, controller中带有 inject:"name" 标签的字段会在RegisterRoute时被注入
+func RegisterNamed(name string, obj interface{}) {
+	container.GetContainer().RegisterNamed(name, obj)
+}
+
+// Provide 注册controller参数的提供者, provider形如 func(*gin.Context) (*Principal, error),
+// controller中类型为*Principal的参数会在每次请求时由provider提供
+func Provide(provider interface{}) error {
+	return param.RegisterProvider(provider)
+}
+
 // GetAutoRouter 获取自动路由注册
 func GetAutoRouter() *AutoRouter {
 	if autoRouter == nil {
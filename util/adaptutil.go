@@ -1,156 +1,72 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"reflect"
 	"strings"
 
 	"github.com/zhyeah/gin-autoreg/log"
 )
 
-// AdaptJSONForDTO 将json转换为dto适配的格式, 并反序列化
-func AdaptJSONForDTO(jsonStr string, objPtr interface{}) error {
-	var adaptObj interface{}
+// AdaptOptions json适配选项
+type AdaptOptions struct {
+	// Strict 拒绝未知字段和类型不匹配的值
+	Strict bool
+	// Coerce 严格模式下仍然允许字符串, 数字和布尔值之间的转换, 非严格模式下总是允许
+	Coerce bool
+	// Naming 没有json标签的字段在json中的名称, 如 user_name 对应字段 UserName, nil 时只按照字段名忽略大小写匹配
+	Naming func(fieldName string) string
+}
 
-	objType := reflect.TypeOf(objPtr)
-	if objType.Kind() == reflect.Ptr {
-		objType = objType.Elem()
-	}
-	log.Logger.Debugf("obj kind: %s", objType.Kind())
-	if objType.Kind() == reflect.Struct || objType.Kind() == reflect.Map {
-		// 首先将jsonStr转换为map
-		adaptObj = make(map[string]interface{})
-	} else if objType.Kind() == reflect.Slice {
-		adaptObj = make([]map[string]interface{}, 0)
-	} else {
-		return nil
-	}
-	err := json.Unmarshal([]byte(jsonStr), &adaptObj)
-	if err != nil {
-		return err
-	}
+// ErrEmptyJSON json中没有任何值时返回
+var ErrEmptyJSON = errors.New("json is empty")
 
-	// 递归的比较对象json字段信息和map的差异, 将字符和数字类型进行转换
-	convertedResult, err := convert(adaptObj, objPtr)
-	if err != nil {
-		json.Unmarshal([]byte(jsonStr), objPtr)
-	} else {
-		bts, err := json.Marshal(&convertedResult)
-		if err != nil {
-			json.Unmarshal([]byte(jsonStr), objPtr)
-		} else {
-			json.Unmarshal(bts, objPtr)
-		}
-	}
-
-	return nil
+// AdaptJSONForDTO 将json转换为dto适配的格式, 并反序列化.
+// 字符串, 数字和布尔值之间会按照dto的字段类型进行转换, 未知字段会被忽略
+func AdaptJSONForDTO(jsonStr string, objPtr interface{}) error {
+	return AdaptJSONForDTOWithOptions(jsonStr, objPtr, nil)
 }
 
-func convert(fieldObj interface{}, obj interface{}) (interface{}, error) {
-	if obj == nil || fieldObj == nil {
-		return fieldObj, nil
+// AdaptJSONForDTOWithOptions 按照objPtr的类型遍历json, 检查并适配后反序列化.
+// 无法适配的值(严格模式下还有未知字段)会以带有json路径的JSONPathErrors返回
+func AdaptJSONForDTOWithOptions(jsonStr string, objPtr interface{}, opts *AdaptOptions) error {
+	if opts == nil {
+		opts = &AdaptOptions{}
 	}
-	objType := reflect.TypeOf(obj)
-	if objType.Kind() == reflect.Ptr {
-		objType = objType.Elem()
-	}
-	fieldType := reflect.TypeOf(fieldObj)
-	if objType.Kind() == fieldType.Kind() && fieldType.Kind() != reflect.Slice {
-		// the type is same, there is no need to do convertion.
-		return fieldObj, nil
-	}
-
-	fieldValue := reflect.ValueOf(fieldObj)
-	switch fieldType.Kind() {
-	case reflect.Float64:
-		if objType.Kind() == reflect.String {
-			return ConvertFloat64ToString(fieldObj.(float64)), nil
-		}
-	case reflect.String:
-		if objType.Kind() == reflect.Int || objType.Kind() == reflect.Int32 || objType.Kind() == reflect.Int64 ||
-			objType.Kind() == reflect.Int8 || objType.Kind() == reflect.Int16 {
-			return ConvertStringToInt64(fieldObj.(string))
-		} else if objType.Kind() == reflect.Uint || objType.Kind() == reflect.Uint32 || objType.Kind() == reflect.Uint64 ||
-			objType.Kind() == reflect.Uint8 || objType.Kind() == reflect.Uint16 {
-			return ConvertStringToUInt64(fieldObj.(string))
-		} else if objType.Kind() == reflect.Float32 || objType.Kind() == reflect.Float64 {
-			return ConvertStringToFloat64(fieldObj.(string))
-		}
-	case reflect.Slice:
-		if objType.Kind() != reflect.Slice {
-			return fieldType, nil
-		}
-		realSlice := make([]interface{}, 0)
-		length := fieldValue.Len()
-		for i := 0; i < length; i++ {
-			curType := objType.Elem()
-			curObj := reflect.New(curType).Elem().Interface()
-			convertedResult, err := convert(fieldValue.Index(i).Interface(), curObj)
-			if err != nil {
-				return fieldObj, err
-			}
-			realSlice = append(realSlice, convertedResult)
-		}
-		return realSlice, nil
-	case reflect.Map:
-
-		// get field json name map
-		fieldJSONMap := getFieldJSONMap(obj)
+	objType := reflect.TypeOf(objPtr)
+	log.Logger.Debugf("obj type: %s", objType)
 
-		realMap := make(map[string]interface{})
-		rg := fieldValue.MapRange()
-		for rg.Next() {
-			fieldJSONName := rg.Key().Interface().(string)
-			if val, ok := fieldJSONMap[fieldJSONName]; ok {
-				convertResult, err := convert(rg.Value().Interface(), val)
-				if err != nil {
-					log.Logger.Errorf("field '%s' resolve value '%v' failed", fieldJSONName, rg.Value().Interface())
-				} else {
-					realMap[fieldJSONName] = convertResult
-				}
-			} else {
-				realMap[fieldJSONName] = rg.Value().Interface()
-			}
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return ErrEmptyJSON
 		}
-		return realMap, nil
+		return err
 	}
 
-	return fieldObj, nil
-}
-
-func getFieldJSONMap(obj interface{}) map[string]interface{} {
-	ret := make(map[string]interface{})
-	objValue := reflect.ValueOf(obj)
-	objType := reflect.TypeOf(obj)
-	if objType.Kind() == reflect.Ptr {
-		objType = objType.Elem()
-		if !objValue.Elem().IsValid() {
-			objValue = reflect.New(objType).Elem()
-		} else {
-			objValue = objValue.Elem()
-		}
+	adapter := &jsonAdapter{
+		strict: opts.Strict,
+		coerce: !opts.Strict || opts.Coerce,
+		naming: opts.Naming,
+		errs:   make(JSONPathErrors, 0),
+	}
+	adapted := adapter.adapt(doc, objType.Elem(), "")
+	if len(adapter.errs) > 0 {
+		return adapter.errs
 	}
-	for j := 0; j < objValue.NumField(); j++ {
-		log.Logger.Debugf("%s %s", objType.Field(j).Name, objType.Field(j).Type.Kind())
-		if objType.Field(j).Type.Kind() == reflect.Struct && objType.Field(j).Anonymous {
-			// recursive collect fields
-			m := getFieldJSONMap(objValue.Field(j).Interface())
-			for k, v := range m {
-				ret[k] = v
-			}
-		}
-
-		jsonTag := objType.Field(j).Tag.Get("json")
-		if strings.Contains(jsonTag, "string") {
-			continue
-		}
 
-		jsonTag = strings.ReplaceAll(jsonTag, "omitempty", "")
-		jsonTag = strings.ReplaceAll(jsonTag, ",", "")
-		if jsonTag == "" {
-			jsonTag = FirstToLower(objType.Field(j).Name)
-		}
-		ret[jsonTag] = objValue.Field(j).Interface()
+	bts, err := json.Marshal(adapted)
+	if err != nil {
+		return err
+	}
+	decoder = json.NewDecoder(bytes.NewReader(bts))
+	if opts.Strict {
+		decoder.DisallowUnknownFields()
 	}
-	return ret
+	return decoder.Decode(objPtr)
 }
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+type adaptNested struct {
+	Level *int   `json:"level"`
+	Name  string `json:"name"`
+}
+
+type adaptDTO struct {
+	Int      int                `json:"int"`
+	Int8     int8               `json:"int8"`
+	Uint     uint               `json:"uint"`
+	Float    float64            `json:"float"`
+	Bool     bool               `json:"bool"`
+	Str      string             `json:"str"`
+	IntPtr   *int               `json:"intPtr"`
+	Nested   *adaptNested       `json:"nested"`
+	Ints     []int              `json:"ints"`
+	Keyed    map[int]string     `json:"keyed"`
+	Values   map[string]float64 `json:"values"`
+	Big      int64              `json:"big"`
+	BigU     uint64             `json:"bigU"`
+	Quoted   int                `json:"quoted,string"`
+	UserName string
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestAdaptJSONForDTOWithOptions(t *testing.T) {
+	strict := &AdaptOptions{Strict: true}
+	strictCoerce := &AdaptOptions{Strict: true, Coerce: true}
+
+	cases := []struct {
+		name string
+		json string
+		opts *AdaptOptions
+		want adaptDTO
+		// paths 期望出错的json路径, 为空时期望成功
+		paths []string
+	}{
+		{name: "string to int", json: `{"int":"12"}`, want: adaptDTO{Int: 12}},
+		{name: "string to uint", json: `{"uint":" 7 "}`, want: adaptDTO{Uint: 7}},
+		{name: "string to float", json: `{"float":"1.5"}`, want: adaptDTO{Float: 1.5}},
+		{name: "string to bool", json: `{"bool":"true"}`, want: adaptDTO{Bool: true}},
+		{name: "number to string", json: `{"str":12}`, want: adaptDTO{Str: "12"}},
+		{name: "bool to string", json: `{"str":true}`, want: adaptDTO{Str: "true"}},
+		{name: "integral float to int", json: `{"int":1e3}`, want: adaptDTO{Int: 1000}},
+		{name: "pointer scalar", json: `{"intPtr":"5"}`, want: adaptDTO{IntPtr: intPtr(5)}},
+		{name: "nested struct pointer", json: `{"nested":{"level":"2","name":3}}`, want: adaptDTO{Nested: &adaptNested{Level: intPtr(2), Name: "3"}}},
+		{name: "slice of scalars", json: `{"ints":["1",2,"3"]}`, want: adaptDTO{Ints: []int{1, 2, 3}}},
+		{name: "typed map keys and values", json: `{"keyed":{"1":"a"},"values":{"x":"0.5"}}`, want: adaptDTO{Keyed: map[int]string{1: "a"}, Values: map[string]float64{"x": 0.5}}},
+		{name: "big ints keep precision", json: `{"big":9007199254740993,"bigU":"18446744073709551615"}`, want: adaptDTO{Big: 9007199254740993, BigU: 18446744073709551615}},
+		{name: "quoted field", json: `{"quoted":"42"}`, want: adaptDTO{Quoted: 42}},
+		{name: "unquoted value of quoted field", json: `{"quoted":42}`, want: adaptDTO{Quoted: 42}},
+		{name: "naming strategy", json: `{"user_name":"x"}`, opts: &AdaptOptions{Naming: ToSnakeCase}, want: adaptDTO{UserName: "x"}},
+		{name: "unknown field ignored", json: `{"nope":1,"int":1}`, want: adaptDTO{Int: 1}},
+		{name: "strict coerce", json: `{"int":"12","str":3}`, opts: strictCoerce, want: adaptDTO{Int: 12, Str: "3"}},
+		{name: "strict exact types", json: `{"int":12,"str":"a","quoted":"1"}`, opts: strict, want: adaptDTO{Int: 12, Str: "a", Quoted: 1}},
+		{name: "strict rejects string to int", json: `{"int":"12"}`, opts: strict, paths: []string{"int"}},
+		{name: "strict rejects number to string", json: `{"str":12}`, opts: strict, paths: []string{"str"}},
+		{name: "strict rejects unknown field", json: `{"nope":1}`, opts: strict, paths: []string{"nope"}},
+		{name: "strict rejects unknown nested field", json: `{"nested":{"nope":1}}`, opts: strict, paths: []string{"nested.nope"}},
+		{name: "fraction to int", json: `{"int":1.5}`, paths: []string{"int"}},
+		{name: "int overflow", json: `{"int8":300}`, paths: []string{"int8"}},
+		{name: "negative uint", json: `{"uint":-1}`, paths: []string{"uint"}},
+		{name: "invalid bool", json: `{"bool":"yes"}`, paths: []string{"bool"}},
+		{name: "invalid nested pointer scalar", json: `{"nested":{"level":"x"}}`, paths: []string{"nested.level"}},
+		{name: "invalid slice item", json: `{"ints":[1,"x"]}`, paths: []string{"ints[1]"}},
+		{name: "invalid map key", json: `{"keyed":{"a":"b"}}`, paths: []string{"keyed.a"}},
+		{name: "invalid map value", json: `{"values":{"x":"y"}}`, paths: []string{"values.x"}},
+		{name: "invalid quoted field", json: `{"quoted":"x"}`, paths: []string{"quoted"}},
+		{name: "object for slice", json: `{"ints":{}}`, paths: []string{"ints"}},
+		{name: "errors of all fields", json: `{"bool":"yes","int":"x"}`, paths: []string{"bool", "int"}},
+		{name: "root mismatch", json: `[1]`, paths: []string{"$"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got adaptDTO
+			err := AdaptJSONForDTOWithOptions(c.json, &got, c.opts)
+			if len(c.paths) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, c.want) {
+					t.Fatalf("got %+v, want %+v", got, c.want)
+				}
+				return
+			}
+			errs, ok := err.(JSONPathErrors)
+			if !ok {
+				t.Fatalf("want JSONPathErrors, got %v", err)
+			}
+			paths := make([]string, 0, len(errs))
+			for _, e := range errs {
+				paths = append(paths, e.Path)
+			}
+			if !reflect.DeepEqual(paths, c.paths) {
+				t.Fatalf("got error paths %v, want %v: %v", paths, c.paths, err)
+			}
+		})
+	}
+}
+
+func TestAdaptJSONForDTOEmpty(t *testing.T) {
+	for _, jsonStr := range []string{"", "  \n"} {
+		var got adaptDTO
+		if err := AdaptJSONForDTOWithOptions(jsonStr, &got, nil); err != ErrEmptyJSON {
+			t.Fatalf("json %q: got %v, want ErrEmptyJSON", jsonStr, err)
+		}
+	}
+}
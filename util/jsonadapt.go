@@ -0,0 +1,463 @@
+package util
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSONPathError json中某个路径上的错误
+type JSONPathError struct {
+	Path    string `json:"path"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (err *JSONPathError) Error() string {
+	return err.Message
+}
+
+// JSONPathErrors json路径错误的集合
+type JSONPathErrors []*JSONPathError
+
+func (errs JSONPathErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// json路径错误的原因
+const (
+	JSONReasonType    = "type"
+	JSONReasonUnknown = "unknown"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// jsonAdapter 按照目标类型遍历json, 检查类型并做必要的转换
+type jsonAdapter struct {
+	strict bool
+	coerce bool
+	naming func(fieldName string) string
+	errs   JSONPathErrors
+}
+
+func (adapter *jsonAdapter) fail(path string, reason string, format string, args ...interface{}) {
+	if path == "" {
+		path = "$"
+	}
+	adapter.errs = append(adapter.errs, &JSONPathError{
+		Path:    path,
+		Reason:  reason,
+		Message: path + ": " + fmt.Sprintf(format, args...),
+	})
+}
+
+func (adapter *jsonAdapter) mismatch(path string, val interface{}, typ reflect.Type) {
+	adapter.fail(path, JSONReasonType, "%s is not %s", describeJSONValue(val), describeType(typ))
+}
+
+func (adapter *jsonAdapter) adapt(val interface{}, typ reflect.Type, path string) interface{} {
+	if val == nil {
+		return nil
+	}
+	// 自定义了反序列化的类型交给其自身处理
+	if typ.Kind() != reflect.Ptr && (reflect.PtrTo(typ).Implements(jsonUnmarshalerType) || reflect.PtrTo(typ).Implements(textUnmarshalerType)) {
+		return val
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return adapter.adapt(val, typ.Elem(), path)
+	case reflect.Interface:
+		return val
+	case reflect.Struct:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			adapter.mismatch(path, val, typ)
+			return val
+		}
+		fields := jsonFieldsOf(typ)
+		ret := make(map[string]interface{}, len(obj))
+		for _, key := range sortedKeys(obj) {
+			item := obj[key]
+			field, ok := fields.find(key, adapter.naming)
+			if !ok {
+				if adapter.strict {
+					adapter.fail(joinJSONPath(path, key), JSONReasonUnknown, "unknown field")
+				}
+				ret[key] = item
+				continue
+			}
+			// 按照命名策略匹配的字段使用其字段名, 使encoding/json能够识别
+			name := key
+			if !strings.EqualFold(field.name, key) {
+				name = field.name
+			}
+			if field.quoted {
+				ret[name] = adapter.adaptQuoted(item, field.typ, joinJSONPath(path, key))
+				continue
+			}
+			ret[name] = adapter.adapt(item, field.typ, joinJSONPath(path, key))
+		}
+		return ret
+	case reflect.Map:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			adapter.mismatch(path, val, typ)
+			return val
+		}
+		keyType := typ.Key()
+		checkKey := IsNumberKind(keyType.Kind()) && !reflect.PtrTo(keyType).Implements(textUnmarshalerType)
+		ret := make(map[string]interface{}, len(obj))
+		for _, key := range sortedKeys(obj) {
+			if checkKey && !isNumberOf(key, keyType) {
+				adapter.fail(joinJSONPath(path, key), JSONReasonType, "key %s is not %s", strconv.Quote(key), describeType(keyType))
+				continue
+			}
+			ret[key] = adapter.adapt(obj[key], typ.Elem(), joinJSONPath(path, key))
+		}
+		return ret
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			if _, ok := val.(string); ok {
+				return val
+			}
+		}
+		arr, ok := val.([]interface{})
+		if !ok {
+			adapter.mismatch(path, val, typ)
+			return val
+		}
+		ret := make([]interface{}, len(arr))
+		for i := range arr {
+			ret[i] = adapter.adapt(arr[i], typ.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+		return ret
+	case reflect.String:
+		switch v := val.(type) {
+		case string:
+			return v
+		case json.Number:
+			if adapter.coerce {
+				return v.String()
+			}
+		case bool:
+			if adapter.coerce {
+				return strconv.FormatBool(v)
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		var str string
+		switch v := val.(type) {
+		case json.Number:
+			str = v.String()
+		case string:
+			if !adapter.coerce {
+				break
+			}
+			str = strings.TrimSpace(v)
+		}
+		if num, ok := adapter.number(str, typ); ok {
+			return num
+		}
+	case reflect.Bool:
+		switch v := val.(type) {
+		case bool:
+			return v
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil && adapter.coerce {
+				return b
+			}
+		case json.Number:
+			if b, err := strconv.ParseBool(v.String()); err == nil && adapter.coerce {
+				return b
+			}
+		}
+	default:
+		return val
+	}
+	adapter.mismatch(path, val, typ)
+	return val
+}
+
+// number 将字符串转换为目标数字类型的json.Number, 数字以字符串形式保留, 不会因float64丢失大整数的精度.
+// 允许转换时, 整数类型也接受1.0, 1e3这类值为整数的小数
+func (adapter *jsonAdapter) number(str string, typ reflect.Type) (json.Number, bool) {
+	if str == "" {
+		return "", false
+	}
+	if isNumberOf(str, typ) {
+		return json.Number(str), true
+	}
+	if !adapter.coerce || typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64 {
+		return "", false
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || f != math.Trunc(f) {
+		return "", false
+	}
+	str = strconv.FormatFloat(f, 'f', -1, 64)
+	if !isNumberOf(str, typ) {
+		return "", false
+	}
+	return json.Number(str), true
+}
+
+// adaptQuoted 处理`json:",string"`的字段, 其值需要是包含目标类型值的字符串
+func (adapter *jsonAdapter) adaptQuoted(val interface{}, typ reflect.Type, path string) interface{} {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if !IsNumberKind(typ.Kind()) && typ.Kind() != reflect.Bool && typ.Kind() != reflect.String {
+		return val
+	}
+	str, ok := val.(string)
+	if !ok {
+		// 允许转换时, 未加引号的值按照目标类型转换后再加上引号
+		if !adapter.coerce || val == nil {
+			return val
+		}
+		adapted := adapter.adapt(val, typ, path)
+		bts, err := json.Marshal(adapted)
+		if err != nil {
+			return val
+		}
+		return string(bts)
+	}
+	if typ.Kind() == reflect.String {
+		return str
+	}
+	var inner interface{}
+	decoder := json.NewDecoder(strings.NewReader(str))
+	decoder.UseNumber()
+	if err := decoder.Decode(&inner); err != nil {
+		inner = str
+	}
+	adapted := adapter.adapt(inner, typ, path)
+	bts, err := json.Marshal(adapted)
+	if err != nil {
+		return val
+	}
+	return string(bts)
+}
+
+// IsNumberKind 判断是否为整数或浮点数类型
+func IsNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isNumberOf 判断字符串是否为目标数字类型的合法值
+func isNumberOf(str string, typ reflect.Type) bool {
+	var err error
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(str, 10, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(str, 10, typ.Bits())
+	default:
+		_, err = strconv.ParseFloat(str, typ.Bits())
+	}
+	return err == nil
+}
+
+// sortedKeys 排序后的key, 使错误的顺序稳定
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinJSONPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describeJSONValue(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	}
+	return fmt.Sprint(val)
+}
+
+func describeType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an unsigned int"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a bool"
+	case reflect.String:
+		return "a string"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return typ.String()
+}
+
+// jsonField 结构体中参与json反序列化的字段
+type jsonField struct {
+	name   string
+	typ    reflect.Type
+	quoted bool
+	tagged bool
+}
+
+type jsonFields []*jsonField
+
+// find 与encoding/json一致, 优先精确匹配, 其次忽略大小写匹配, 最后按照命名策略匹配没有json标签的字段
+func (fields jsonFields) find(key string, naming func(fieldName string) string) (*jsonField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	if naming == nil {
+		return nil, false
+	}
+	for _, field := range fields {
+		if !field.tagged && naming(field.name) == key {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+var jsonFieldsCache sync.Map
+
+// jsonFieldsOf 获取结构体参与json反序列化的字段, 包括匿名嵌入结构体的提升字段
+func jsonFieldsOf(typ reflect.Type) jsonFields {
+	if val, ok := jsonFieldsCache.Load(typ); ok {
+		return val.(jsonFields)
+	}
+	fields := make(jsonFields, 0)
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		tags := strings.Split(structField.Tag.Get("json"), ",")
+		if tags[0] == "-" && len(tags) == 1 {
+			continue
+		}
+		fieldType := structField.Type
+		if structField.Anonymous && tags[0] == "" {
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				fields = append(fields, jsonFieldsOf(fieldType)...)
+				continue
+			}
+		}
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		field := &jsonField{name: tags[0], typ: structField.Type, tagged: tags[0] != ""}
+		if field.name == "" {
+			field.name = structField.Name
+		}
+		for _, opt := range tags[1:] {
+			if opt == "string" {
+				field.quoted = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	jsonFieldsCache.Store(typ, fields)
+	return fields
+}
+
+// RenameJSONFields 将typ序列化得到的json中没有json标签的字段按照命名策略重命名, 用于生成参数示例
+func RenameJSONFields(jsonStr string, typ reflect.Type, naming func(fieldName string) string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonStr))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return "", err
+	}
+	bts, err := json.Marshal(renameJSONFields(doc, typ, naming))
+	if err != nil {
+		return "", err
+	}
+	return string(bts), nil
+}
+
+func renameJSONFields(val interface{}, typ reflect.Type, naming func(fieldName string) string) interface{} {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		if typ.Kind() == reflect.Map {
+			for key, item := range v {
+				ret[key] = renameJSONFields(item, typ.Elem(), naming)
+			}
+			return ret
+		}
+		if typ.Kind() != reflect.Struct {
+			return v
+		}
+		fields := jsonFieldsOf(typ)
+		for key, item := range v {
+			field, ok := fields.find(key, nil)
+			if !ok {
+				ret[key] = item
+				continue
+			}
+			if !field.tagged {
+				key = naming(field.name)
+			}
+			ret[key] = renameJSONFields(item, field.typ, naming)
+		}
+		return ret
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return v
+		}
+		ret := make([]interface{}, len(v))
+		for i := range v {
+			ret[i] = renameJSONFields(v[i], typ.Elem(), naming)
+		}
+		return ret
+	}
+	return val
+}
@@ -7,13 +7,17 @@ import (
 // ReflectInvokeMethod 通过反射调用方法
 func ReflectInvokeMethod(object interface{}, methodName string, args ...interface{}) []interface{} {
 
+	objectValue := reflect.ValueOf(object)
+	method := objectValue.MethodByName(methodName)
+
 	inputs := make([]reflect.Value, len(args))
 	for i, arg := range args {
 		inputs[i] = reflect.ValueOf(arg)
+		// nil参数使用对应类型的零值
+		if arg == nil && !method.Type().IsVariadic() && i < method.Type().NumIn() {
+			inputs[i] = reflect.Zero(method.Type().In(i))
+		}
 	}
-
-	objectValue := reflect.ValueOf(object)
-	method := objectValue.MethodByName(methodName)
 	ret := method.Call(inputs)
 
 	retList := []interface{}{}
@@ -3,6 +3,7 @@ package util
 import (
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // ConvertStringToInt string 转 int
@@ -141,3 +142,61 @@ func ConvertFloat64ToString(f float64) string {
 func FirstToLower(input string) string {
 	return strings.ToLower(input[0:1]) + input[1:]
 }
+
+// ToSnakeCase 将驼峰形式的名称转换为下划线形式, 如 UserID -> user_id
+func ToSnakeCase(input string) string {
+	return strings.Join(splitCamelWords(input), "_")
+}
+
+// ToKebabCase 将驼峰形式的名称转换为中划线形式, 如 UserID -> user-id
+func ToKebabCase(input string) string {
+	return strings.Join(splitCamelWords(input), "-")
+}
+
+// splitCamelWords 按照驼峰拆分单词并转为小写, 连续的大写字母视为一个单词, 如 HTTPServer -> http, server
+func splitCamelWords(input string) []string {
+	runes := []rune(input)
+	words := make([]string, 0)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+		if cur == '_' || cur == '-' {
+			if i > start {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+			}
+			start = i + 1
+			continue
+		}
+		if boundary && i > start {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// ParseByteSize 解析字节大小, 支持B/KB/MB/GB后缀(1024进制), 如 "10MB"
+func ParseByteSize(str string) (int64, error) {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	unit := int64(1)
+	for _, suffix := range []struct {
+		name string
+		size int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(str, suffix.name) {
+			unit = suffix.size
+			str = strings.TrimSpace(strings.TrimSuffix(str, suffix.name))
+			break
+		}
+	}
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return val * unit, nil
+}
@@ -0,0 +1,20 @@
+package vo
+
+// Event 服务器推送事件(SSE), Name和ID为空时不输出, Retry大于0时告诉客户端重连的间隔(毫秒).
+// Data为字符串或[]byte时原样输出, 其他值序列化为JSON
+type Event struct {
+	Name  string
+	ID    string
+	Retry int
+	Data  interface{}
+}
+
+// EventSink 服务器推送事件的发送端, controller参数中有EventSink时路由以text/event-stream响应,
+// controller返回后推送结束
+type EventSink interface {
+	// Send 发送并立即flush一个事件, data为Event或*Event时使用其事件名和ID, 其他值作为事件的数据.
+	// 客户端断开连接后返回错误
+	Send(data interface{}) error
+	// Done 客户端断开连接时关闭
+	Done() <-chan struct{}
+}
@@ -0,0 +1,37 @@
+package vo
+
+import (
+	"io"
+	"time"
+)
+
+// File 文件下载响应, 由路由直接写入响应体, 不经过ResponseHandler.
+// Reader实现io.ReadSeeker时支持Range请求, 实现io.Closer时在写入后关闭
+type File struct {
+	// Name 文件名, 非空时设置Content-Disposition响应头, 并在ContentType为空时用于推断类型
+	Name string
+	// ContentType 为空时按文件名推断, 无法推断时为application/octet-stream
+	ContentType string
+	Reader      io.Reader
+	// Size 文件大小, 为0且Reader可以Seek时自动获取, 否则不设置Content-Length
+	Size int64
+	// ModTime 修改时间, 非零值时设置Last-Modified响应头并支持条件请求
+	ModTime time.Time
+	// Inline 为true时浏览器直接显示而不是下载
+	Inline bool
+}
+
+// NewFile 创建文件下载响应
+func NewFile(name string, reader io.Reader) *File {
+	return &File{
+		Name:   name,
+		Reader: reader,
+	}
+}
+
+// Raw 原始数据响应, Data原样写入响应体, 不经过ResponseHandler
+type Raw struct {
+	// ContentType 为空时由数据内容推断
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,10 @@
+package vo
+
+// PagedResponse 分页响应, 路由会根据其设置Link和X-Total-Count响应头, 再交给ResponseHandler包装
+type PagedResponse struct {
+	Total      int64       `json:"total" xml:"total" yaml:"total"`
+	Page       int         `json:"page,omitempty" xml:"page,omitempty" yaml:"page,omitempty"`
+	Size       int         `json:"size,omitempty" xml:"size,omitempty" yaml:"size,omitempty"`
+	NextCursor string      `json:"nextCursor,omitempty" xml:"nextCursor,omitempty" yaml:"nextCursor,omitempty"`
+	Items      interface{} `json:"items" xml:"items" yaml:"items"`
+}
@@ -0,0 +1,52 @@
+package vo
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Problem RFC 7807 problem+json格式的错误响应
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Errors   interface{} `json:"errors,omitempty"`
+	// Extensions 扩展成员, 与标准成员同名的会被忽略
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON 将扩展成员按照key的顺序输出在标准成员之后
+func (problem *Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	bts, err := json.Marshal((*plain)(problem))
+	if err != nil || len(problem.Extensions) == 0 {
+		return bts, err
+	}
+	keys := make([]string, 0, len(problem.Extensions))
+	for key := range problem.Extensions {
+		switch key {
+		case "type", "title", "status", "detail", "instance", "errors":
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(bts[:len(bts)-1])
+	for _, key := range keys {
+		keyBts, _ := json.Marshal(key)
+		valBts, err := json.Marshal(problem.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(keyBts)
+		buf.WriteByte(':')
+		buf.Write(valBts)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
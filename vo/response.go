@@ -1,8 +1,11 @@
 package vo
 
+import "encoding/xml"
+
 // GeneralResponse 通用response结构
 type GeneralResponse struct {
-	Code    int         `json:"retCode"`
-	Message string      `json:"errMsg"`
-	Data    interface{} `json:"body"`
+	XMLName xml.Name    `json:"-" xml:"response" yaml:"-"`
+	Code    int         `json:"retCode" xml:"retCode" yaml:"retCode"`
+	Message string      `json:"errMsg" xml:"errMsg" yaml:"errMsg"`
+	Data    interface{} `json:"body" xml:"body,omitempty" yaml:"body"`
 }
@@ -0,0 +1,82 @@
+package vo
+
+import "net/http"
+
+// StatusCoder 可由controller返回, 指定成功响应的HTTP状态码
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// HeaderSetter 可由controller返回, 在响应前设置响应头
+type HeaderSetter interface {
+	SetHeaders(header http.Header)
+}
+
+// CookieSetter 可由controller返回, 在响应前设置cookie
+type CookieSetter interface {
+	Cookies() []*http.Cookie
+}
+
+// Result 携带状态码, 响应头和cookie的返回值, 路由会先应用它们, 再将Data交给ResponseHandler.
+// 状态码需要在100到599之间, 会被设置到ctx.Writer上, 自定义的ResponseHandler写入响应时需要使用
+// ResponseStatus或ctx.Writer.Status()得到的状态码, 如 ctx.JSON(200, ...) 会覆盖它
+type Result struct {
+	Status  int
+	Headers http.Header
+	Cookie  []*http.Cookie
+	Data    interface{}
+}
+
+// NewResult 创建返回值
+func NewResult(data interface{}) *Result {
+	return &Result{
+		Headers: make(http.Header),
+		Cookie:  make([]*http.Cookie, 0),
+		Data:    data,
+	}
+}
+
+// Created 创建201返回值, 并设置Location响应头
+func Created(location string, data interface{}) *Result {
+	return NewResult(data).WithStatus(http.StatusCreated).WithHeader("Location", location)
+}
+
+// WithStatus 设置状态码
+func (result *Result) WithStatus(status int) *Result {
+	result.Status = status
+	return result
+}
+
+// WithHeader 添加响应头
+func (result *Result) WithHeader(key string, val string) *Result {
+	if result.Headers == nil {
+		result.Headers = make(http.Header)
+	}
+	result.Headers.Add(key, val)
+	return result
+}
+
+// WithCookie 添加cookie
+func (result *Result) WithCookie(cookie *http.Cookie) *Result {
+	result.Cookie = append(result.Cookie, cookie)
+	return result
+}
+
+// StatusCode 实现StatusCoder
+func (result *Result) StatusCode() int {
+	return result.Status
+}
+
+// SetHeaders 实现HeaderSetter
+func (result *Result) SetHeaders(header http.Header) {
+	for key, vals := range result.Headers {
+		for _, val := range vals {
+			header.Add(key, val)
+		}
+	}
+}
+
+// Cookies 实现CookieSetter
+func (result *Result) Cookies() []*http.Cookie {
+	return result.Cookie
+}